@@ -0,0 +1,56 @@
+// Package apply implements the "apply" command, which executes a rename plan previously saved by the "rename" command.
+package apply
+
+import (
+	"strings"
+
+	"github.com/TheoBrigitte/evansky/pkg/renamer"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "apply [flags] <plan file>",
+		Short: "apply a saved rename plan",
+		Long: `Apply a rename plan saved with "rename --plan-out", without querying any provider. ` +
+			`Entries whose source changed since planning are refused.`,
+		RunE: runner,
+		Args: cobra.ExactArgs(1),
+	}
+
+	flags *Flags
+)
+
+func init() {
+	flags = NewFlags()
+
+	Cmd.PersistentFlags().BoolVarP(&flags.force, "force", "f", false, "overwrite existing destination files")
+	Cmd.PersistentFlags().StringVar(&flags.journalDir, "journal-dir", "", "journal directory used to undo runs (default: $XDG_CACHE_HOME/evansky/journal or $HOME/.cache/evansky/journal)")
+	Cmd.PersistentFlags().StringVar(&flags.outputFormat, "output-format", renamer.OutputFormatText, "format of the results: "+strings.Join(renamer.OutputFormats, ", "))
+	Cmd.PersistentFlags().BoolVar(&flags.write, "write", false, "actually perform the rename operation (default: false)")
+}
+
+func runner(cmd *cobra.Command, args []string) error {
+	plan, err := renamer.ReadPlan(args[0])
+	if err != nil {
+		return err
+	}
+
+	journalDir := flags.journalDir
+	if journalDir == "" {
+		journalDir, err = renamer.DefaultJournalDir()
+		if err != nil {
+			return err
+		}
+	}
+
+	renameOptions := renamer.Options{
+		Force:        flags.force,
+		JournalDir:   journalDir,
+		OutputFormat: flags.outputFormat,
+		Write:        flags.write,
+	}
+
+	return renamer.Apply(cmd.Context(), plan, renameOptions)
+}
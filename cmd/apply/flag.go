@@ -0,0 +1,12 @@
+package apply
+
+type Flags struct {
+	force        bool
+	journalDir   string
+	outputFormat string
+	write        bool
+}
+
+func NewFlags() *Flags {
+	return &Flags{}
+}
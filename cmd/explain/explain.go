@@ -0,0 +1,224 @@
+// Package explain implements the "explain" command, which shows how the match of a path was chosen.
+package explain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/TheoBrigitte/evansky/pkg/parser"
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/register"
+	"github.com/TheoBrigitte/evansky/pkg/renamer"
+	formatregister "github.com/TheoBrigitte/evansky/pkg/renamer/format/register"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "explain [flags] <file | directory>",
+		Short: "explain how the match of a path is chosen",
+		Long: `Explain how the match of a path is chosen: the information parsed from its name and the patterns which matched, ` +
+			`the queries sent to each provider, the movie or TV show decision, the season or episode resolution ` +
+			`and every candidate along with the components of its score. ` +
+			`For a directory, every entry scanned is explained on its own. ` +
+			`It accepts the same flags as rename, nothing is ever renamed.`,
+		RunE: runner,
+		Args: cobra.ExactArgs(1),
+	}
+
+	flags *Flags
+)
+
+func init() {
+	flags = NewFlags()
+
+	flags.rename.AddFlags(Cmd.PersistentFlags())
+
+	register.Initialize(Cmd)
+	formatregister.Initialize(Cmd)
+}
+
+func runner(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	providers, err := register.GetProviders()
+	if err != nil {
+		return err
+	}
+
+	formatter, err := formatregister.GetFormatter()
+	if err != nil {
+		return err
+	}
+
+	renameOptions, sourceOptions, err := flags.rename.Options(formatter)
+	if err != nil {
+		return err
+	}
+	// Entries are scanned one at a time so their steps are listed in order, and nothing is written.
+	renameOptions.Write = false
+	sourceOptions.Jobs = 1
+
+	return explain(cmd.Context(), cmd.OutOrStdout(), path, providers, renameOptions, sourceOptions)
+}
+
+// explain scans path and writes, for every entry, the information parsed from its name, the steps taken to find its media,
+// and its outcome along with its candidates.
+func explain(ctx context.Context, w io.Writer, path string, providers []provider.Interface, renameOptions renamer.Options, sourceOptions source.Options) error {
+	r, err := renamer.New([]string{path}, providers, renameOptions)
+	if err != nil {
+		return err
+	}
+
+	// Steps are grouped by the entry they belong to, entries are listed in the order they were scanned.
+	var mu sync.Mutex
+	var paths []string
+	steps := make(map[string][]string)
+	ctx = provider.WithTracer(ctx, func(entry, step string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := steps[entry]; !ok {
+			paths = append(paths, entry)
+		}
+		steps[entry] = append(steps[entry], step)
+	})
+
+	plan, err := r.Plan(ctx, sourceOptions)
+	if err != nil {
+		return err
+	}
+
+	var titleRegex *regexp.Regexp
+	if sourceOptions.TitleRegex != "" {
+		titleRegex, err = regexp.Compile(sourceOptions.TitleRegex)
+		if err != nil {
+			return fmt.Errorf("invalid title regex: %w", err)
+		}
+	}
+
+	entries := make(map[string]renamer.Entry, len(plan.Entries))
+	for _, e := range plan.Entries {
+		entries[filepath.Clean(e.Source)] = e
+	}
+
+	root := filepath.Clean(path)
+	for _, entry := range paths {
+		if entry == "" {
+			// Steps taken outside of any entry.
+			writeSteps(w, steps[entry])
+			fmt.Fprintln(w)
+			continue
+		}
+
+		p := filepath.Clean(entry)
+		fmt.Fprintf(w, "Entry %s:\n", p)
+
+		// The query override only applies to the scanned path, as it does when scanning.
+		query := filepath.Base(p)
+		if sourceOptions.Query != "" && p == root {
+			query = sourceOptions.Query
+		}
+		err = writeParsed(w, query, titleRegex)
+		if err != nil {
+			return err
+		}
+
+		writeSteps(w, steps[entry])
+
+		if e, ok := entries[p]; ok {
+			writeEntry(w, e)
+			delete(entries, p)
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Entries which were not looked up, such as excluded files and companions.
+	for _, e := range plan.Entries {
+		if _, ok := entries[filepath.Clean(e.Source)]; !ok {
+			continue
+		}
+
+		fmt.Fprintf(w, "Entry %s:\n", e.Source)
+		writeEntry(w, e)
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
+
+// writeParsed writes the information parsed from query and the patterns which matched.
+// The title regex applies as it does when scanning.
+func writeParsed(w io.Writer, query string, titleRegex *regexp.Regexp) error {
+	if titleRegex != nil {
+		if matches := titleRegex.FindStringSubmatch(query); len(matches) > 1 {
+			query = matches[len(matches)-1]
+		}
+	}
+
+	info, matches, err := parser.Explain(query)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", query, err)
+	}
+
+	parsed, err := json.MarshalIndent(info, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "  Parsed %q:\n    %s\n", query, parsed)
+
+	fmt.Fprintln(w, "  Patterns:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "    FIELD\tVALUE\tMATCHED\tPATTERN")
+	for _, m := range matches {
+		fmt.Fprintf(tw, "    %s\t%q\t%q\t%s\n", m.Field, m.Value, m.Raw, m.Pattern)
+	}
+
+	return tw.Flush()
+}
+
+// writeSteps writes the steps taken to find the media of an entry, in order.
+func writeSteps(w io.Writer, steps []string) {
+	fmt.Fprintln(w, "  Steps:")
+	for i, step := range steps {
+		fmt.Fprintf(w, "    %d. %s\n", i+1, step)
+	}
+}
+
+// writeEntry writes the outcome of the entry and its candidates, best first.
+func writeEntry(w io.Writer, e renamer.Entry) {
+	if e.Error != nil {
+		fmt.Fprintf(w, "  error: %v\n", e.Error)
+	} else {
+		fmt.Fprintf(w, "  destination: %s\n", e.Destination)
+	}
+
+	if len(e.Candidates) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "  #\tMATCH\tID\tTYPE\tTITLE\tYEAR\tPOPULARITY\tSIMILARITY\tTITLE SCORE\tYEAR SCORE\tPOPULARITY SCORE\tTOTAL\tCONFIDENCE\t")
+	matched, _ := e.MatchCandidate()
+	for i, c := range e.Candidates {
+		match := ""
+		if c.Provider == matched.Provider && c.ID == matched.ID && c.MediaType == matched.MediaType {
+			match = "*"
+		}
+
+		fmt.Fprintf(tw, "  %d\t%s\t%s:%d\t%s\t%s\t%d\t%d\t", i, match, c.Provider, c.ID, c.MediaType, c.Name, c.Year, c.Popularity)
+		if b := c.Breakdown; b != nil {
+			fmt.Fprintf(tw, "%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n", b.TitleSimilarity, b.Title, b.Year, b.Popularity, b.Total, b.Confidence)
+		} else {
+			fmt.Fprintf(tw, "\t\t\t\t%.2f\t\t\n", c.Score)
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,15 @@
+package explain
+
+import (
+	"github.com/TheoBrigitte/evansky/cmd/rename"
+)
+
+type Flags struct {
+	rename *rename.Flags
+}
+
+func NewFlags() *Flags {
+	return &Flags{
+		rename: rename.NewFlags(),
+	}
+}
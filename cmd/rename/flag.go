@@ -1,24 +1,157 @@
 package rename
 
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/TheoBrigitte/evansky/pkg/renamer"
+	"github.com/TheoBrigitte/evansky/pkg/renamer/format"
+	"github.com/TheoBrigitte/evansky/pkg/score"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
 type Flags struct {
-	excludeGlob        []string
-	excludeRegex       string
-	includeGlob        []string
-	includeRegex       string
-	force              bool
-	language           string
-	mediaExtensions    []string
-	output             string
-	query              string
-	queryLanguage      string
-	renameMode         string
-	stripComponents    int
-	subtitleExtensions []string
-	titleRegex         string
-	skipExisting       bool
-	write              bool
+	companionExtensions []string
+	directories         bool
+	excludeGlob         []string
+	excludeRegex        string
+	includeGlob         []string
+	includeRegex        string
+	jobs                int
+	force               bool
+	journalDir          string
+	language            string
+	matchMetric         string
+	matchWeights        score.Weights
+	minConfidence       float64
+	yearTolerance       int
+	mediaExtensions     []string
+	output              string
+	outputFormat        string
+	planOut             string
+	query               string
+	queryLanguage       string
+	renameMode          string
+	stripComponents     int
+	subtitleExtensions  []string
+	titleRegex          string
+	skipExisting        bool
+	write               bool
 }
 
 func NewFlags() *Flags {
 	return &Flags{}
 }
+
+// AddFlags adds the flags controlling the rename pipeline to fs.
+// They are shared by the commands running this pipeline.
+func (f *Flags) AddFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&f.companionExtensions, "companion-ext", []string{"nfo", "jpg", "jpeg", "png", "tbn", "mka", "xml"}, "companion file extensions, renamed along with their media")
+	fs.BoolVar(&f.directories, "directories", false, "rename whole directories instead of the files they contain")
+	fs.StringSliceVar(&f.excludeGlob, "exclude", nil, "exclude files or directories matching the given glob pattern")
+	fs.StringVar(&f.excludeRegex, "exclude-regex", "", "exclude files or directories matching the given regular expression")
+	fs.StringSliceVar(&f.includeGlob, "include", nil, "include files or directories matching the given glob pattern")
+	fs.StringVar(&f.includeRegex, "include-regex", "", "only rename files matching the given regular expression")
+	fs.BoolVarP(&f.force, "force", "f", false, "overwrite existing destination files")
+	fs.IntVarP(&f.jobs, "jobs", "j", 4, "number of entries scanned concurrently")
+	fs.StringVar(&f.journalDir, "journal-dir", "", "journal directory used to undo runs (default: $XDG_CACHE_HOME/evansky/journal or $HOME/.cache/evansky/journal)")
+	fs.StringVar(&f.language, "language", "en", "language used for destination names (ISO 639-1 code)")
+	fs.StringVar(&f.matchMetric, "match-metric", score.MetricJaroWinkler, "string similarity metric comparing titles: "+strings.Join(score.Metrics, ", "))
+	fs.Float64Var(&f.matchWeights.Title, "match-weight-title", score.DefaultWeights.Title, "weight of the title mismatch in match scores")
+	fs.Float64Var(&f.matchWeights.Year, "match-weight-year", score.DefaultWeights.Year, "weight of the year distance in match scores")
+	fs.Float64Var(&f.matchWeights.Popularity, "match-weight-popularity", score.DefaultWeights.Popularity, "weight of the lack of popularity in match scores")
+	fs.Float64Var(&f.minConfidence, "min-confidence", 0, "minimum confidence of a match (0-1), entries below it are reported as needing review instead of being renamed, 0 accepts every match, its meaning depends on --match-metric")
+	fs.IntVar(&f.yearTolerance, "year-tolerance", 0, "number of years a match may differ from the year in the name without lowering its confidence")
+	fs.StringSliceVar(&f.mediaExtensions, "media-ext", []string{"mkv", "mp4", "avi", "mov", "wmv", "flv", "mpg", "mpeg"}, "media file extensions to consider")
+	fs.StringVarP(&f.output, "output", "o", "", "output directory (default: same as source)")
+	fs.StringVar(&f.outputFormat, "output-format", renamer.OutputFormatText, "format of the results: "+strings.Join(renamer.OutputFormats, ", "))
+	fs.StringVar(&f.query, "query", "", "search query override")
+	fs.StringVar(&f.queryLanguage, "query-language", "", "language query override")
+	fs.StringVar(&f.renameMode, "mode", "symlink", "rename mode: symlink, hardlink, copy, move")
+	fs.IntVar(&f.stripComponents, "strip-components", 0, "number of leading path components to strip from source paths")
+	fs.StringVar(&f.titleRegex, "title-regex", "", "regular expression to extract title from file or directory name")
+	fs.StringSliceVar(&f.subtitleExtensions, "subtitle-ext", []string{"srt", "idx", "sub"}, "subtitles extensions to consider")
+	fs.BoolVar(&f.skipExisting, "skip-existing", false, "skip renaming if destination dir already exists")
+	fs.BoolVar(&f.write, "write", false, "actually perform the rename operation (default: false)")
+}
+
+// Options returns the renamer and source options described by the flags.
+func (f *Flags) Options(formatter format.Formatter) (renamer.Options, source.Options, error) {
+	output := f.output
+	if output != "" {
+		info, err := os.Lstat(output)
+		if err != nil && !os.IsNotExist(err) {
+			return renamer.Options{}, source.Options{}, err
+		}
+		if err == nil && !info.IsDir() {
+			return renamer.Options{}, source.Options{}, fmt.Errorf("output is not a directory: %s", output)
+		}
+		output = filepath.Clean(output)
+	}
+
+	if f.jobs < 1 {
+		return renamer.Options{}, source.Options{}, fmt.Errorf("jobs must be at least 1: %d", f.jobs)
+	}
+
+	if !slices.Contains(renamer.OutputFormats, f.outputFormat) {
+		return renamer.Options{}, source.Options{}, fmt.Errorf("unknown output format: %s", f.outputFormat)
+	}
+
+	if f.minConfidence < 0 || f.minConfidence > 1 {
+		return renamer.Options{}, source.Options{}, fmt.Errorf("min confidence must be between 0 and 1: %v", f.minConfidence)
+	}
+
+	scorer, err := score.New(score.Options{
+		Metric:        f.matchMetric,
+		Weights:       f.matchWeights,
+		YearTolerance: f.yearTolerance,
+	})
+	if err != nil {
+		return renamer.Options{}, source.Options{}, err
+	}
+
+	journalDir := f.journalDir
+	if journalDir == "" {
+		journalDir, err = renamer.DefaultJournalDir()
+		if err != nil {
+			return renamer.Options{}, source.Options{}, err
+		}
+	}
+
+	renameOptions := renamer.Options{
+		Directories:  f.directories,
+		Force:        f.force,
+		Formatter:    formatter,
+		JournalDir:   journalDir,
+		Output:       output,
+		OutputFormat: f.outputFormat,
+		RenameMode:   f.renameMode,
+		SkipExisting: f.skipExisting,
+		Write:        f.write,
+	}
+
+	sourceOptions := source.Options{
+		Query:           f.query,
+		QueryLanguage:   f.queryLanguage,
+		Language:        f.language,
+		ExcludeGlob:     f.excludeGlob,
+		ExcludeRegex:    f.excludeRegex,
+		IncludeGlob:     f.includeGlob,
+		IncludeRegex:    f.includeRegex,
+		Jobs:            f.jobs,
+		Scorer:          scorer,
+		MinConfidence:   f.minConfidence,
+		MediaExts:       f.mediaExtensions,
+		SubtitleExts:    f.subtitleExtensions,
+		CompanionExts:   f.companionExtensions,
+		StripComponents: f.stripComponents,
+		TitleRegex:      f.titleRegex,
+	}
+
+	return renameOptions, sourceOptions, nil
+}
@@ -2,15 +2,11 @@
 package rename
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
-
 	"github.com/TheoBrigitte/evansky/pkg/provider/register"
 	"github.com/TheoBrigitte/evansky/pkg/renamer"
-	"github.com/TheoBrigitte/evansky/pkg/renamer/format"
-	"github.com/TheoBrigitte/evansky/pkg/source"
+	formatregister "github.com/TheoBrigitte/evansky/pkg/renamer/format/register"
 
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
@@ -30,24 +26,11 @@ var (
 func init() {
 	flags = NewFlags()
 
-	Cmd.PersistentFlags().StringSliceVar(&flags.excludeGlob, "exclude", nil, "exclude files or directories matching the given glob pattern")
-	Cmd.PersistentFlags().StringVar(&flags.excludeRegex, "exclude-regex", "", "exclude files or directories matching the given regular expression")
-	Cmd.PersistentFlags().StringSliceVar(&flags.includeGlob, "include", nil, "include files or directories matching the given glob pattern")
-	Cmd.PersistentFlags().StringVar(&flags.includeRegex, "include-regex", "", "only rename files matching the given regular expression")
-	Cmd.PersistentFlags().BoolVarP(&flags.force, "force", "f", false, "overwrite existing destination files")
-	Cmd.PersistentFlags().StringVar(&flags.language, "language", "en", "language used for destination names (ISO 639-1 code)")
-	Cmd.PersistentFlags().StringSliceVar(&flags.mediaExtensions, "media-ext", []string{"mkv", "mp4", "avi", "mov", "wmv", "flv", "mpg", "mpeg"}, "media file extensions to consider")
-	Cmd.PersistentFlags().StringVarP(&flags.output, "output", "o", "", "output directory (default: same as source)")
-	Cmd.PersistentFlags().StringVar(&flags.query, "query", "", "search query override")
-	Cmd.PersistentFlags().StringVar(&flags.queryLanguage, "query-language", "", "language query override")
-	Cmd.PersistentFlags().StringVar(&flags.renameMode, "mode", "symlink", "rename mode: symlink, hardlink, copy, move")
-	Cmd.PersistentFlags().IntVar(&flags.stripComponents, "strip-components", 0, "number of leading path components to strip from source paths")
-	Cmd.PersistentFlags().StringVar(&flags.titleRegex, "title-regex", "", "regular expression to extract title from file or directory name")
-	Cmd.PersistentFlags().StringSliceVar(&flags.subtitleExtensions, "subtitle-ext", []string{"srt", "idx", "sub"}, "subtitles extensions to consider")
-	Cmd.PersistentFlags().BoolVar(&flags.skipExisting, "skip-existing", false, "skip renaming if destination dir already exists")
-	Cmd.PersistentFlags().BoolVar(&flags.write, "write", false, "actually perform the rename operation (default: false)")
+	flags.AddFlags(Cmd.PersistentFlags())
+	Cmd.PersistentFlags().StringVar(&flags.planOut, "plan-out", "", "save the rename plan to the given file, to be applied later with the apply command")
 
 	register.Initialize(Cmd)
+	formatregister.Initialize(Cmd)
 }
 
 func runner(cmd *cobra.Command, args []string) error {
@@ -56,28 +39,14 @@ func runner(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	formatter := format.NewJellyfinFormatter()
-
-	if flags.output != "" {
-		info, err := os.Lstat(flags.output)
-		if err != nil && !os.IsNotExist(err) {
-			return err
-		}
-		if err == nil && !info.IsDir() {
-			return fmt.Errorf("output is not a directory: %s", flags.output)
-		}
-		flags.output = filepath.Clean(flags.output)
+	formatter, err := formatregister.GetFormatter()
+	if err != nil {
+		return err
 	}
 
-	renameOptions := renamer.Options{
-		Force:        flags.force,
-		Formatter:    formatter,
-		Output:       flags.output,
-		RenameMode:   flags.renameMode,
-		SkipExisting: flags.skipExisting,
-	}
-	if flags.write {
-		renameOptions.Write = true
+	renameOptions, sourceOptions, err := flags.Options(formatter)
+	if err != nil {
+		return err
 	}
 
 	r, err := renamer.New(args, providers, renameOptions)
@@ -85,19 +54,19 @@ func runner(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	sourceOptions := source.Options{
-		Query:           flags.query,
-		QueryLanguage:   flags.queryLanguage,
-		Language:        flags.language,
-		ExcludeGlob:     flags.excludeGlob,
-		ExcludeRegex:    flags.excludeRegex,
-		IncludeGlob:     flags.includeGlob,
-		IncludeRegex:    flags.includeRegex,
-		MediaExts:       flags.mediaExtensions,
-		SubtitleExts:    flags.subtitleExtensions,
-		StripComponents: flags.stripComponents,
-		TitleRegex:      flags.titleRegex,
+	plan, err := r.Plan(cmd.Context(), sourceOptions)
+	if err != nil {
+		return err
+	}
+
+	// An interrupted scan is incomplete, it is not saved.
+	if flags.planOut != "" && cmd.Context().Err() == nil {
+		err = renamer.WritePlan(flags.planOut, plan)
+		if err != nil {
+			return err
+		}
+		log.Info().Str("plan", flags.planOut).Int("entries", len(plan.Entries)).Msg("saved rename plan")
 	}
 
-	return r.Run(sourceOptions)
+	return r.Execute(cmd.Context(), plan)
 }
@@ -2,14 +2,22 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/prometheus/common/version"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"github.com/TheoBrigitte/evansky/cmd/apply"
+	"github.com/TheoBrigitte/evansky/cmd/explain"
 	cmdlog "github.com/TheoBrigitte/evansky/cmd/log"
 	"github.com/TheoBrigitte/evansky/cmd/rename"
+	"github.com/TheoBrigitte/evansky/cmd/serve"
+	"github.com/TheoBrigitte/evansky/cmd/undo"
+	"github.com/TheoBrigitte/evansky/cmd/watch"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -28,15 +36,29 @@ var (
 
 func init() {
 	rootCmd.SetVersionTemplate(`{{.Version}}{{"\n"}}`)
+	rootCmd.AddCommand(apply.Cmd)
+	rootCmd.AddCommand(explain.Cmd)
 	rootCmd.AddCommand(rename.Cmd)
+	rootCmd.AddCommand(serve.Cmd)
+	rootCmd.AddCommand(undo.Cmd)
+	rootCmd.AddCommand(watch.Cmd)
 	cmdlog.AddFlags(rootCmd)
 	rootCmd.InitDefaultCompletionCmd()
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Commands are given a context which is canceled on SIGINT or SIGTERM, a second signal kills the process.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// Restore the default behavior, so a second signal stops the process right away.
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		log.Err(err).Send()
 		os.Exit(1)
@@ -0,0 +1,17 @@
+package serve
+
+import (
+	"github.com/TheoBrigitte/evansky/cmd/rename"
+)
+
+type Flags struct {
+	listen string
+
+	rename *rename.Flags
+}
+
+func NewFlags() *Flags {
+	return &Flags{
+		rename: rename.NewFlags(),
+	}
+}
@@ -0,0 +1,96 @@
+// Package serve implements the "serve" command, which exposes a REST API to scan, review and execute rename plans.
+package serve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider/register"
+	formatregister "github.com/TheoBrigitte/evansky/pkg/renamer/format/register"
+	"github.com/TheoBrigitte/evansky/pkg/server"
+)
+
+// shutdownTimeout is how long pending requests are waited for on shutdown.
+const shutdownTimeout = 30 * time.Second
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "serve [flags]",
+		Short: "serve a REST API to rename directory content",
+		Long: `Serve a local REST API to scan paths into rename plans, review them and execute them. ` +
+			`Plans list the candidates of every entry along with their scores, entries can be approved, ` +
+			`matched to another candidate or given another destination. Only approved entries are executed. ` +
+			`It accepts the same flags as rename, which apply to every plan, --write is required to actually rename.`,
+		RunE: runner,
+		Args: cobra.NoArgs,
+	}
+
+	flags *Flags
+)
+
+func init() {
+	flags = NewFlags()
+
+	flags.rename.AddFlags(Cmd.PersistentFlags())
+	Cmd.PersistentFlags().StringVar(&flags.listen, "listen", "127.0.0.1:8080", "address to listen on")
+
+	register.Initialize(Cmd)
+	formatregister.Initialize(Cmd)
+}
+
+func runner(cmd *cobra.Command, args []string) error {
+	// Providers are created once, they are shared by all requests along with their HTTP cache.
+	providers, err := register.GetProviders()
+	if err != nil {
+		return err
+	}
+
+	formatter, err := formatregister.GetFormatter()
+	if err != nil {
+		return err
+	}
+
+	renameOptions, sourceOptions, err := flags.rename.Options(formatter)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              flags.listen,
+		Handler:           server.New(providers, renameOptions, sourceOptions).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx := cmd.Context()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Info().Str("address", flags.listen).Msg("serving")
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info().Msg("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err = srv.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+package undo
+
+type Flags struct {
+	journalDir string
+	list       bool
+	write      bool
+}
+
+func NewFlags() *Flags {
+	return &Flags{}
+}
@@ -0,0 +1,61 @@
+// Package undo implements the "undo" command, which reverses a previous run using its journal.
+package undo
+
+import (
+	"fmt"
+
+	"github.com/TheoBrigitte/evansky/pkg/renamer"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "undo [flags] [run-id]",
+		Short: "undo a previous run",
+		Long: `Undo a previous run using its journal, by default the most recent one. ` +
+			`Operations are reversed in reverse order: written files are removed, moved files are moved back, ` +
+			`replaced destinations are restored where possible and created directories are removed when empty.`,
+		RunE: runner,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	flags *Flags
+)
+
+func init() {
+	flags = NewFlags()
+
+	Cmd.PersistentFlags().StringVar(&flags.journalDir, "journal-dir", "", "journal directory (default: $XDG_CACHE_HOME/evansky/journal or $HOME/.cache/evansky/journal)")
+	Cmd.PersistentFlags().BoolVar(&flags.list, "list", false, "list runs which can be undone")
+	Cmd.PersistentFlags().BoolVar(&flags.write, "write", false, "actually perform the undo operation (default: false)")
+}
+
+func runner(cmd *cobra.Command, args []string) error {
+	journalDir := flags.journalDir
+	if journalDir == "" {
+		dir, err := renamer.DefaultJournalDir()
+		if err != nil {
+			return err
+		}
+		journalDir = dir
+	}
+
+	if flags.list {
+		runs, err := renamer.Runs(journalDir)
+		if err != nil {
+			return err
+		}
+		for _, run := range runs {
+			fmt.Fprintln(cmd.OutOrStdout(), run)
+		}
+		return nil
+	}
+
+	var runID string
+	if len(args) > 0 {
+		runID = args[0]
+	}
+
+	return renamer.Undo(cmd.Context(), journalDir, runID, flags.write)
+}
@@ -0,0 +1,20 @@
+package watch
+
+import (
+	"time"
+
+	"github.com/TheoBrigitte/evansky/cmd/rename"
+)
+
+type Flags struct {
+	incompleteExtensions []string
+	stableFor            time.Duration
+
+	rename *rename.Flags
+}
+
+func NewFlags() *Flags {
+	return &Flags{
+		rename: rename.NewFlags(),
+	}
+}
@@ -0,0 +1,119 @@
+// Package watch implements the "watch" command, which renames new entries of directories as soon as they are completely written.
+package watch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider/register"
+	"github.com/TheoBrigitte/evansky/pkg/renamer"
+	formatregister "github.com/TheoBrigitte/evansky/pkg/renamer/format/register"
+	"github.com/TheoBrigitte/evansky/pkg/watcher"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "watch [flags] <directory>...",
+		Short: "rename new directory content as it arrives",
+		Long: `Watch directories and rename every new file or directory once it is completely written, ` +
+			`i.e. its size did not change for --stable-for and it does not contain incomplete downloads (--incomplete-ext). ` +
+			`Only new entries are processed, existing content is left untouched. ` +
+			`--output is required and must be outside of the watched directories. Linux only.`,
+		RunE: runner,
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	flags *Flags
+)
+
+func init() {
+	flags = NewFlags()
+
+	flags.rename.AddFlags(Cmd.PersistentFlags())
+	Cmd.PersistentFlags().StringSliceVar(&flags.incompleteExtensions, "incomplete-ext", []string{"part", "!qB"}, "extensions of files still being downloaded")
+	Cmd.PersistentFlags().DurationVar(&flags.stableFor, "stable-for", 30*time.Second, "how long the size of a new entry must stay unchanged before it is renamed")
+
+	register.Initialize(Cmd)
+	formatregister.Initialize(Cmd)
+}
+
+func runner(cmd *cobra.Command, args []string) error {
+	providers, err := register.GetProviders()
+	if err != nil {
+		return err
+	}
+
+	formatter, err := formatregister.GetFormatter()
+	if err != nil {
+		return err
+	}
+
+	renameOptions, sourceOptions, err := flags.rename.Options(formatter)
+	if err != nil {
+		return err
+	}
+
+	if renameOptions.Output == "" {
+		// Renaming in place would create new entries in the watched directories.
+		return fmt.Errorf("--output is required")
+	}
+	output, err := filepath.Abs(renameOptions.Output)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		path, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("not a directory: %s", arg)
+		}
+
+		if output == path || strings.HasPrefix(output, path+string(os.PathSeparator)) {
+			return fmt.Errorf("output %s must be outside of watched directory %s", renameOptions.Output, arg)
+		}
+
+		paths = append(paths, path)
+	}
+
+	ctx := cmd.Context()
+
+	w := watcher.New(paths, watcher.Options{
+		StableFor:      flags.stableFor,
+		IncompleteExts: flags.incompleteExtensions,
+	})
+
+	return w.Run(ctx, func(path string) {
+		// Run the rename pipeline on the new entry only.
+		r, err := renamer.New([]string{path}, providers, renameOptions)
+		if err != nil {
+			log.Err(err).Str("path", path).Msg("failed to rename")
+			return
+		}
+
+		plan, err := r.Plan(ctx, sourceOptions)
+		if err != nil {
+			log.Err(err).Str("path", path).Msg("failed to rename")
+			return
+		}
+
+		err = r.Execute(ctx, plan)
+		if err != nil {
+			log.Err(err).Str("path", path).Msg("failed to rename")
+		}
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -16,7 +18,10 @@ type TorrentInfo struct {
 	Title      string
 	Season     int    `json:"season,omitempty"`
 	Episode    int    `json:"episode,omitempty"`
+	EpisodeEnd int    `json:"episodeEnd,omitempty"`
 	Year       int    `json:"year,omitempty"`
+	Edition    string `json:"edition,omitempty"`
+	Part       int    `json:"part,omitempty"`
 	Resolution string `json:"resolution,omitempty"`
 	Quality    string `json:"quality,omitempty"`
 	Codec      string `json:"codec,omitempty"`
@@ -67,8 +72,36 @@ func setField(tor *TorrentInfo, field, raw, val string) {
 	}
 }
 
+// Match is a pattern which matched while parsing a filename.
+type Match struct {
+	// Field is the name of the field set by the pattern
+	Field string
+	// Pattern is the regular expression of the pattern
+	Pattern string
+	// Raw is the matched text, removed from the title
+	Raw string
+	// Value is the clean value set on the field
+	Value string
+}
+
 // Parse breaks up the given filename in TorrentInfo
 func Parse(filename string) (*TorrentInfo, error) {
+	return parse(filename, nil)
+}
+
+// Explain breaks up the given filename in TorrentInfo like Parse,
+// and also returns the patterns which matched, in order.
+func Explain(filename string) (*TorrentInfo, []Match, error) {
+	var matches []Match
+	tor, err := parse(filename, func(m Match) {
+		matches = append(matches, m)
+	})
+
+	return tor, matches, err
+}
+
+// parse breaks up the given filename in TorrentInfo, reporting every pattern which matched to match when not nil.
+func parse(filename string, match func(Match)) (*TorrentInfo, error) {
 	// tor holds the resulting parsed information
 	tor := &TorrentInfo{}
 
@@ -123,6 +156,10 @@ func Parse(filename string) (*TorrentInfo, error) {
 
 		// Update title index
 		index := strings.Index(cleanName, matches[1])
+		if slices.Contains(afterYearPatterns, pattern.name) && tor.Year != 0 && index < titleEndIndex {
+			// Match is part of the title
+			continue
+		}
 		if index == 0 {
 			// Move title start index after this match
 			titleStartIndex = len(matches[1])
@@ -137,7 +174,14 @@ func Parse(filename string) (*TorrentInfo, error) {
 			}
 		}
 
+		if normalize, ok := normalizers[pattern.name]; ok {
+			matches[2] = normalize(matches[2])
+		}
+
 		setField(tor, pattern.name, matches[1], matches[2])
+		if match != nil {
+			match(Match{Field: pattern.name, Pattern: pattern.re.String(), Raw: matches[1], Value: matches[2]})
+		}
 
 		// Set pattern as already matched
 		patternMatches[pattern.name] = matches[2]
@@ -193,11 +237,13 @@ func CleanTitle(raw string) string {
 
 // containsPartOf returns true if s contains part of any of the values in patternMatches
 // A part of a value is contained when its first or last 3 characters are found in s.
-// Patterns for year, season, and episode are ignored, this is because they are highly likely to return a false positive.
+// Patterns for year, season, episode and episode end are ignored, this is because they are highly likely to return a false positive.
+// Edition is ignored as well, as editions like EXTENDED or UNRATED are also matched by their own pattern,
+// and so is part, as its single digit value would match almost anything.
 func containsPartOf(s string, patternMatches map[string]string) bool {
 	for name, value := range patternMatches {
 		switch name {
-		case "season", "episode":
+		case "season", "episode", "episodeEnd", "edition", "part":
 			continue
 		}
 
@@ -216,3 +262,29 @@ func containsPartOf(s string, patternMatches map[string]string) bool {
 
 	return false
 }
+
+// normalizers transform the clean value matched by a pattern before it is set.
+var normalizers = map[string]func(string) string{
+	"edition": normalizeEdition,
+	"part":    normalizePart,
+}
+
+// normalizePart returns the part number matched by the part pattern, letters are numbered from a=1.
+func normalizePart(value string) string {
+	if len(value) == 1 && unicode.IsLetter(rune(value[0])) {
+		return strconv.Itoa(int(unicode.ToLower(rune(value[0]))-'a') + 1)
+	}
+
+	return value
+}
+
+// normalizeEdition returns the display name of the edition matched by the edition pattern.
+func normalizeEdition(value string) string {
+	for _, e := range editions {
+		if e.re.MatchString(value) {
+			return e.name
+		}
+	}
+
+	return value
+}
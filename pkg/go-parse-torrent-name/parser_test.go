@@ -111,6 +111,23 @@ var testData = []string{
 	"The.Wire.S01E02.MULTi.VOSTFR+FRENCH.720p.WEB-DL.x265.HEVC-QC.mkv",
 	"Mud - Sur les rives du Mississippi (2012) (Mud)",
 	"Drive.2011.MULTi.(VFF+VFQ).1080p.BluRay.HDLight.AC3.x264-Zone80.mkv",
+	"Blade.Runner.1982.Final.Cut.1080p.BluRay.x264-HDMaNiAcS",
+	"The.Final.Cut.2004.720p.BluRay.x264-SiNNERS",
+	"Aliens.1986.Directors.Cut.1080p.BluRay.DTS.x264-CtrlHD",
+	"Apocalypse Now (1979) Theatrical Cut 1080p BluRay x265",
+	"The.Seventh.Seal.1957.Criterion.Collection.720p.BluRay.x264-DON",
+	"Interstellar.2014.IMAX.2160p.WEB-DL.DD5.1.H265-NAISU",
+	"Lost.S01E01-E02.Pilot.720p.BluRay.x264-SiNNERS",
+	"The.Office.US.S05E01E02.Weight.Loss.1080p.WEB-DL.DD5.1.H264",
+	"Doctor Who 4x12-13 The Stolen Earth HDTV x264-FoV",
+	"Breaking.Bad.S05E15-16.720p.HDTV.x264-EVOLVE",
+	"The.Godfather.1972.CD1.720p.BluRay.x264-GRP.avi",
+	"Lawrence of Arabia (1962) Disc B 1080p BluRay",
+	"Harry.Potter.and.the.Deathly.Hallows.Part.1.2010.1080p.BluRay.x264-SPARKS",
+	"Kill Bill 2003 part2 DVDRip XviD",
+	"Amelie.2001.DVD5.PAL.x264-GRP",
+	"Amelie (2001) DVD9 x264-GRP",
+	"Amelie.2001.DVD2.XviD-GRP",
 }
 
 func TestParser(t *testing.T) {
@@ -23,14 +23,28 @@ var patterns = []struct {
 	{"season", false, reflect.Int, regexp.MustCompile(`(?i)(([0-9]{1,}))x`)},
 	// Episode in 1x01 format (case insensitive)
 	{"episode", false, reflect.Int, regexp.MustCompile(`(?i)([0-9]{1,}x([0-9]{2})(?:[^\w]|$))`)},
+	// Episode in S01E01E02 format (case insensitive)
+	{"episode", false, reflect.Int, regexp.MustCompile(`(?i)(s(?:aison ?|eason ?)?[0-9]{1,}e([0-9]{2,})e[0-9]{2,}(?:[^\w]|$))`)},
 	// Episode in S01E01 format (case insensitive)
 	{"episode", false, reflect.Int, regexp.MustCompile(`(?i)(s(?:aison ?|eason ?)?[0-9]{1,}(?:e| ?episode)([0-9]{2,})(?:[^\w]|$))`)},
 	// Episode in - 01 format (case insensitive)
 	{"episode", false, reflect.Int, regexp.MustCompile(`(-\s+([0-9]{2,})(?:[^\w]|$))`)},
+	// Last episode of a range in S01E01-E02, S01E01-02 or S01E01E02 format (case insensitive)
+	{"episodeEnd", false, reflect.Int, regexp.MustCompile(`(?i)s(?:aison ?|eason ?)?[0-9]{1,}e[0-9]{2,}((?:-e?|e)([0-9]{2,}))(?:[^\w]|$)`)},
+	// Last episode of a range in 1x01-02 format (case insensitive)
+	{"episodeEnd", false, reflect.Int, regexp.MustCompile(`(?i)[0-9]{1,}x[0-9]{2}(-([0-9]{2}))(?:[^\w]|$)`)},
 	// Year ranges and take the first year, e.g. 1989-2016 => 1989
 	{"year", true, reflect.Int, regexp.MustCompile(`\b(((?:19[0-9]|20[0-9])[0-9])-(?:19[0-9]|20[0-9])[0-9])\b`)},
 	// Years from 1900 to 2099
 	{"year", true, reflect.Int, regexp.MustCompile(`\b(((?:19[0-9]|20[0-9])[0-9]))\b`)},
+	// Edition like Director's Cut, Extended, Unrated, Criterion
+	{"edition", false, reflect.String, regexp.MustCompile(`(?i)\b((director'?s[ ._-]?cut|theatrical(?:[ ._-]?(?:cut|edition|version))?|extended(?:[ ._-]?(?:cut|edition))?|unrated|remastered|criterion(?:[ ._-]?collection)?|imax(?:[ ._-]?edition)?|final[ ._-]?cut))\b`)},
+	// Part of multi-part movies like CD1, part2, disc 1, pt.3
+	{"part", false, reflect.Int, regexp.MustCompile(`(?i)\b((?:cd|p(?:ar)?t|dis[ck])[ ._-]?([0-9]{1,2}))\b`)},
+	// Part of multi-part movies like DVD1, DVD5 and DVD9 are disc formats rather than parts
+	{"part", false, reflect.Int, regexp.MustCompile(`(?i)\b(dvd[ ._-]?([1-46-8]|[0-9]{2}))\b`)},
+	// Part of multi-part movies like disc A, part-B
+	{"part", false, reflect.Int, regexp.MustCompile(`(?i)\b((?:cd|dvd|p(?:ar)?t|dis[ck])[ ._-]([a-d]))\b`)},
 	// Resolution like 720p, 1080p, 2160p
 	{"resolution", false, reflect.String, regexp.MustCompile(`\b(([0-9]{3,4}p))\b`)},
 	// Quality like HDTS, DVDRip, BluRay, WEB-DL, CAM, HDRip, etc.
@@ -68,6 +82,25 @@ var patterns = []struct {
 	{"threeD", false, reflect.Bool, regexp.MustCompile(`(?i)\b((3D))\b`)},
 }
 
+// afterYearPatterns lists the patterns which are ignored when found before the year, as they are likely part of the title.
+// E.g. "The Final Cut (2004)" or "Harry Potter and the Deathly Hallows Part 1 (2010)".
+var afterYearPatterns = []string{"edition", "part"}
+
+// editions maps the editions matched by the edition pattern to their display name.
+var editions = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"Director's Cut", regexp.MustCompile(`(?i)^director`)},
+	{"Theatrical", regexp.MustCompile(`(?i)^theatrical`)},
+	{"Extended", regexp.MustCompile(`(?i)^extended`)},
+	{"Unrated", regexp.MustCompile(`(?i)^unrated`)},
+	{"Remastered", regexp.MustCompile(`(?i)^remastered`)},
+	{"Criterion", regexp.MustCompile(`(?i)^criterion`)},
+	{"IMAX", regexp.MustCompile(`(?i)^imax`)},
+	{"Final Cut", regexp.MustCompile(`(?i)^final`)},
+}
+
 func init() {
 	for _, pat := range patterns {
 		if pat.re.NumSubexp() != 2 {
@@ -13,11 +13,16 @@ import (
 type Options struct {
 	CacheDir string
 	TTL      time.Duration
+	// Transport is used to make requests which are not cached, http.DefaultTransport if nil.
+	Transport http.RoundTripper
 }
 
 // New returns a new http.Client with caching capabilities if ttl > 0.
 func New(o Options) *http.Client {
-	var transport http.RoundTripper
+	transport := o.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
 
 	if o.TTL > 0 {
 		// use osFs to store the base files on disk
@@ -41,11 +46,10 @@ func New(o Options) *http.Client {
 		})
 
 		transport = &Transport{
-			Cache:    c.AsHTTPCache(),
-			CacheKey: cacheKey,
+			Transport: transport,
+			Cache:     c.AsHTTPCache(),
+			CacheKey:  cacheKey,
 		}
-	} else {
-		transport = http.DefaultTransport
 	}
 
 	return &http.Client{
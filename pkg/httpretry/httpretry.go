@@ -0,0 +1,191 @@
+// Package httpretry provides a http.RoundTripper which throttles requests
+// and retries them on transient failures, with exponential backoff and jitter.
+package httpretry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Options struct {
+	// RequestsPerSecond limits the rate of requests, 0 to disable.
+	RequestsPerSecond float64
+	// Burst is the number of requests allowed at once before being throttled.
+	Burst int
+	// MaxRetries is the number of retries of a request after a transient failure.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, it doubles on every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries, including delays asked by the server.
+	MaxDelay time.Duration
+}
+
+// Transport throttles requests and retries them on transient failures.
+// Requests are retried on network errors and on 429 and 5xx responses, honouring the Retry-After header.
+// Other failures are permanent and returned right away.
+type Transport struct {
+	// Transport is the RoundTripper used to make requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+	// Limiter is shared by every request made through this transport.
+	Limiter *Limiter
+
+	o Options
+}
+
+// StatusError is returned when a request still fails with a transient status code once retries are exhausted.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http request failed: %s", e.Status)
+}
+
+// New returns a Transport making requests through transport, with the given options.
+func New(transport http.RoundTripper, o Options) *Transport {
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = 500 * time.Millisecond
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = 30 * time.Second
+	}
+
+	return &Transport{
+		Transport: transport,
+		Limiter:   NewLimiter(o.RequestsPerSecond, o.Burst),
+		o:         o,
+	}
+}
+
+func (t *Transport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip makes the request, waiting for the limiter and retrying it on transient failures.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		err := t.Limiter.Wait(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt > 0 && req.Body != nil {
+			// Requests with a body can only be retried when it can be read again.
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.transport().RoundTrip(req)
+		if err != nil && !IsTransient(err) {
+			return nil, err
+		}
+		if err == nil && !isTransientStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			err = &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if attempt >= t.o.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			return nil, err
+		}
+
+		delay := t.backoff(attempt)
+		if retryAfter > 0 {
+			// The server told us when to come back, hold back every request until then.
+			delay = min(retryAfter, t.o.MaxDelay)
+			t.Limiter.Pause(delay)
+		}
+
+		log.Debug().Err(err).Str("url", req.URL.Redacted()).Int("attempt", attempt+1).Dur("delay", delay).Msg("retrying request")
+
+		err = sleep(req.Context(), delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the delay before the given retry attempt.
+// The delay doubles on every attempt up to the maximum delay, and half of it is random to spread retries of concurrent requests.
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := min(t.o.BaseDelay<<attempt, t.o.MaxDelay)
+	if delay <= 0 {
+		// Overflow on large attempts.
+		delay = t.o.MaxDelay
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// IsTransient returns whether err is a transient failure, which may succeed when retried.
+func IsTransient(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return isTransientStatus(statusErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isTransientStatus returns whether a response with the given status code may succeed when retried.
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, given either in seconds or as a date.
+// It returns 0 when the header is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
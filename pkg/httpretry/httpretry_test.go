@@ -0,0 +1,168 @@
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{input: "", expected: 0},
+		{input: "0", expected: 0},
+		{input: "5", expected: 5 * time.Second},
+		{input: "-5", expected: 0},
+		{input: "soon", expected: 0},
+		{input: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), expected: 0},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("parseRetryAfter_%03d", i), func(t *testing.T) {
+			result := parseRetryAfter(tc.input)
+			if result != tc.expected {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", tc.input, tc.expected, result)
+			}
+		})
+	}
+
+	// HTTP dates have a precision of one second.
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	result := parseRetryAfter(date)
+	if result <= 58*time.Second || result > time.Minute {
+		t.Errorf("For input '%s', expected about '%s' but got '%s'", date, time.Minute, result)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tr := New(nil, Options{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 0, expected: 100 * time.Millisecond},
+		{attempt: 1, expected: 200 * time.Millisecond},
+		{attempt: 3, expected: 800 * time.Millisecond},
+		{attempt: 4, expected: time.Second},
+		{attempt: 10, expected: time.Second},
+		// Shifts past the size of a duration overflow.
+		{attempt: 100, expected: time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("backoff_%03d", tc.attempt), func(t *testing.T) {
+			for range 100 {
+				result := tr.backoff(tc.attempt)
+				if result < tc.expected/2 || result > tc.expected {
+					t.Fatalf("For attempt %d, expected a delay between '%s' and '%s' but got '%s'", tc.attempt, tc.expected/2, tc.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{err: &StatusError{StatusCode: http.StatusTooManyRequests}, expected: true},
+		{err: &StatusError{StatusCode: http.StatusInternalServerError}, expected: true},
+		{err: &StatusError{StatusCode: http.StatusBadGateway}, expected: true},
+		{err: &StatusError{StatusCode: http.StatusServiceUnavailable}, expected: true},
+		{err: &StatusError{StatusCode: http.StatusGatewayTimeout}, expected: true},
+		{err: &StatusError{StatusCode: http.StatusBadRequest}, expected: false},
+		{err: &StatusError{StatusCode: http.StatusUnauthorized}, expected: false},
+		{err: &StatusError{StatusCode: http.StatusNotFound}, expected: false},
+		{err: &StatusError{StatusCode: http.StatusNotImplemented}, expected: false},
+		{err: fmt.Errorf("search: %w", &StatusError{StatusCode: http.StatusServiceUnavailable}), expected: true},
+		{err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, expected: true},
+		{err: fmt.Errorf("read: %w", syscall.ECONNRESET), expected: true},
+		{err: io.ErrUnexpectedEOF, expected: true},
+		{err: context.DeadlineExceeded, expected: true},
+		{err: context.Canceled, expected: false},
+		{err: errors.New("invalid request"), expected: false},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("IsTransient_%03d", i), func(t *testing.T) {
+			result := IsTransient(tc.err)
+			if result != tc.expected {
+				t.Errorf("For input '%v', expected '%t' but got '%t'", tc.err, tc.expected, result)
+			}
+		})
+	}
+}
+
+// testServer returns a server answering with the given status codes in turn, and the number of requests it received.
+func testServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		i := int(requests.Add(1)) - 1
+		status := statuses[min(i, len(statuses)-1)]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+
+	return s, &requests
+}
+
+func TestRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		statuses []int
+		status   int
+		err      bool
+		requests int32
+	}{
+		{name: "retried", statuses: []int{http.StatusTooManyRequests, http.StatusOK}, status: http.StatusOK, requests: 2},
+		{name: "fail fast", statuses: []int{http.StatusNotFound}, status: http.StatusNotFound, requests: 1},
+		{name: "retries exhausted", statuses: []int{http.StatusServiceUnavailable}, err: true, requests: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, requests := testServer(t, tc.statuses...)
+			client := &http.Client{Transport: New(nil, Options{MaxRetries: 2, BaseDelay: time.Millisecond})}
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, s.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if tc.err {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tc.statuses[0] {
+					t.Errorf("expected a status error, got %v", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tc.status {
+					t.Errorf("expected status %d, got %d", tc.status, resp.StatusCode)
+				}
+			}
+			if n := requests.Load(); n != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, n)
+			}
+		})
+	}
+}
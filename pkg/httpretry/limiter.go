@@ -0,0 +1,91 @@
+package httpretry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket rate limiter, safe for concurrent use.
+// The bucket holds up to burst tokens and is refilled at rate tokens per second.
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	// pausedUntil holds back every request, it is set when the server asks to retry later.
+	pausedUntil time.Time
+}
+
+// NewLimiter returns a limiter allowing rate requests per second, with bursts of up to burst requests.
+// A rate of 0 or less disables the limit.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(max(burst, 1)),
+		tokens: float64(max(burst, 1)),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request is allowed or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	return sleep(ctx, delay)
+}
+
+// Pause holds back every request for d, unless they already are for longer.
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// reserve takes a token from the bucket and returns how long to wait before using it.
+// Tokens may go negative, later requests then wait for the bucket to refill.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var delay time.Duration
+	if l.pausedUntil.After(now) {
+		delay = l.pausedUntil.Sub(now)
+	}
+
+	if l.rate <= 0 {
+		return delay
+	}
+
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens < 0 {
+		delay = max(delay, time.Duration(-l.tokens/l.rate*float64(time.Second)))
+	}
+
+	return delay
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(10, 2)
+	for i := range 2 {
+		if delay := l.reserve(); delay != 0 {
+			t.Fatalf("expected request %d of the burst not to wait, got '%s'", i, delay)
+		}
+	}
+	// The bucket is empty, the next token comes in 1/10s.
+	if delay := l.reserve(); delay <= 50*time.Millisecond || delay > 100*time.Millisecond {
+		t.Errorf("expected request after the burst to wait about 100ms, got '%s'", delay)
+	}
+
+	unlimited := NewLimiter(0, 0)
+	for range 100 {
+		if delay := unlimited.reserve(); delay != 0 {
+			t.Fatalf("expected requests without rate not to wait, got '%s'", delay)
+		}
+	}
+
+	unlimited.Pause(time.Hour)
+	unlimited.Pause(time.Minute)
+	if delay := unlimited.reserve(); delay <= time.Minute {
+		t.Errorf("expected the longest pause to hold requests back, got '%s'", delay)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err := unlimited.Wait(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected wait to stop with its context, got %v", err)
+	}
+}
@@ -7,10 +7,18 @@ import (
 
 type Info = parse.TorrentInfo
 
+// Match is a pattern which matched while parsing a file name.
+type Match = parse.Match
+
 func Parse(filename string) (*Info, error) {
 	return parse.Parse(filename)
 }
 
+// Explain parses filename like Parse, and also returns the patterns which matched, in order.
+func Explain(filename string) (*Info, []Match, error) {
+	return parse.Explain(filename)
+}
+
 func CleanTitle(title string) string {
 	return parse.CleanTitle(title)
 }
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/TheoBrigitte/evansky/pkg/score"
+)
+
+// Candidate is a possible match of a search, along with its score.
+type Candidate struct {
+	Provider  string    `json:"provider"`
+	ID        int       `json:"id"`
+	MediaType MediaType `json:"media_type"`
+	Name      string    `json:"name"`
+	Year      int       `json:"year,omitempty"`
+	// Popularity of the candidate, from 0 to 100
+	Popularity int `json:"popularity,omitempty"`
+	// Score is the combined score of the candidate, lower is better
+	Score float64 `json:"score"`
+	// Breakdown holds the components of the score.
+	Breakdown *score.Breakdown `json:"breakdown,omitempty"`
+
+	// Response is the response of the candidate, it is only available in memory.
+	Response Response `json:"-"`
+}
+
+// NewCandidate returns the candidate for response r with the given score.
+func NewCandidate(r Response, b score.Breakdown) Candidate {
+	return Candidate{
+		Provider:   r.GetProvider(),
+		ID:         r.GetID(),
+		MediaType:  MediaTypeOf(r),
+		Name:       r.GetName(),
+		Year:       r.GetDate().Year(),
+		Popularity: r.GetPopularity(),
+		Score:      b.Total,
+		Breakdown:  &b,
+		Response:   r,
+	}
+}
+
+// CandidateOf returns the candidate of r among its own candidates, which holds its score.
+func CandidateOf(r Response) (Candidate, bool) {
+	for _, c := range r.GetCandidates() {
+		if c.Provider == r.GetProvider() && c.ID == r.GetID() && c.MediaType == MediaTypeOf(r) {
+			return c, true
+		}
+	}
+
+	return Candidate{}, false
+}
+
+// CompareCandidates orders candidates by score, best first.
+func CompareCandidates(a, b Candidate) int {
+	return cmp.Compare(a.Score, b.Score)
+}
+
+// MergeCandidates returns the candidates of every list, best first.
+// Candidates listed more than once, as happens when merging a list into one of its sources again, are kept once.
+func MergeCandidates(lists ...[]Candidate) []Candidate {
+	type key struct {
+		provider  string
+		mediaType MediaType
+		id        int
+	}
+
+	seen := make(map[key]bool)
+	var candidates []Candidate
+	for _, list := range lists {
+		for _, c := range list {
+			k := key{c.Provider, c.MediaType, c.ID}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			candidates = append(candidates, c)
+		}
+	}
+	slices.SortStableFunc(candidates, CompareCandidates)
+
+	return candidates
+}
@@ -5,4 +5,6 @@ import "fmt"
 var (
 	// ErrNoResults is returned when no results are found.
 	ErrNoResult = fmt.Errorf("no result found")
+	// ErrRetryable is returned when a provider call failed on a transient error, it may succeed later.
+	ErrRetryable = fmt.Errorf("retryable error")
 )
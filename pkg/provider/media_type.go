@@ -28,3 +28,41 @@ func (m MediaType) String() string {
 		return "unknown"
 	}
 }
+
+// ParseMediaType returns the MediaType matching the given string representation.
+func ParseMediaType(s string) MediaType {
+	for m := MediaTypeMovie; m <= MediaTypeCollection; m++ {
+		if m.String() == s {
+			return m
+		}
+	}
+
+	return MediaTypeUnknown
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m MediaType) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *MediaType) UnmarshalText(text []byte) error {
+	*m = ParseMediaType(string(text))
+	return nil
+}
+
+// MediaTypeOf returns the MediaType of the given response.
+func MediaTypeOf(r Response) MediaType {
+	switch r.(type) {
+	case ResponseMovie:
+		return MediaTypeMovie
+	case ResponseTV:
+		return MediaTypeTV
+	case ResponseTVSeason:
+		return MediaTypeTVSeason
+	case ResponseTVEpisode:
+		return MediaTypeTVEpisode
+	default:
+		return MediaTypeUnknown
+	}
+}
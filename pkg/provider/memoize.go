@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// memoized wraps a provider and caches its search results,
+// so entries searching for the same media share the same response.
+type memoized struct {
+	Interface
+
+	mu      sync.Mutex
+	lookups map[lookupKey]*lookup
+}
+
+// lookupKey identifies a search.
+type lookupKey struct {
+	provider            string
+	mediaType           MediaType
+	query               string
+	year                int
+	queryLanguage       string
+	destinationLanguage string
+}
+
+// lookup is the result of a search, done is closed once it is known.
+type lookup struct {
+	done  chan struct{}
+	resp  Response
+	score float64
+	err   error
+}
+
+// Memoize returns a provider which caches the search results of p, for as long as it is used.
+// Concurrent identical searches wait for the first one instead of querying p again.
+// Failed searches are not cached, except when no result was found.
+func Memoize(p Interface) Interface {
+	return &memoized{
+		Interface: p,
+		lookups:   make(map[lookupKey]*lookup),
+	}
+}
+
+func (m *memoized) SearchMovie(ctx context.Context, req Request) (ResponseMovie, float64, error) {
+	resp, score, err := m.search(ctx, MediaTypeMovie, req, func(ctx context.Context, req Request) (Response, float64, error) {
+		return m.Interface.SearchMovie(ctx, req)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	movie, _ := resp.(ResponseMovie)
+	return movie, score, nil
+}
+
+func (m *memoized) SearchTV(ctx context.Context, req Request) (ResponseTV, float64, error) {
+	resp, score, err := m.search(ctx, MediaTypeTV, req, func(ctx context.Context, req Request) (Response, float64, error) {
+		return m.Interface.SearchTV(ctx, req)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tv, _ := resp.(ResponseTV)
+	return tv, score, nil
+}
+
+// search returns the cached result of the search described by mediaType and req, calling search when unknown.
+func (m *memoized) search(ctx context.Context, mediaType MediaType, req Request, search func(context.Context, Request) (Response, float64, error)) (Response, float64, error) {
+	key := lookupKey{
+		provider:            m.Name(),
+		mediaType:           mediaType,
+		query:               normalizeQuery(req.Query),
+		year:                req.Year,
+		queryLanguage:       req.QueryLanguage,
+		destinationLanguage: req.DestinationLanguage,
+	}
+
+	m.mu.Lock()
+	l, ok := m.lookups[key]
+	if ok {
+		m.mu.Unlock()
+		Trace(ctx, "%s: reusing the %s search for %q of a previous entry", m.Name(), mediaType, req.Query)
+
+		select {
+		case <-l.done:
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		}
+		if l.err != nil {
+			return nil, 0, l.err
+		}
+		return l.resp, l.score, nil
+	}
+
+	l = &lookup{done: make(chan struct{})}
+	m.lookups[key] = l
+	m.mu.Unlock()
+
+	l.resp, l.score, l.err = search(ctx, req)
+	if l.err != nil && !errors.Is(l.err, ErrNoResult) {
+		// Transient failures may succeed later, let the next search try again.
+		// Searches already waiting for this one get its error.
+		m.mu.Lock()
+		delete(m.lookups, key)
+		m.mu.Unlock()
+	}
+	close(l.done)
+
+	return l.resp, l.score, l.err
+}
+
+// normalizeQuery returns query in lower case with its words separated by a single space.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
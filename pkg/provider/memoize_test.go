@@ -0,0 +1,128 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+)
+
+// blockingProvider holds searches back until release is closed, and signals started on the first one.
+type blockingProvider struct {
+	*providertest.Provider
+
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) SearchMovie(ctx context.Context, req provider.Request) (provider.ResponseMovie, float64, error) {
+	p.once.Do(func() { close(p.started) })
+	<-p.release
+
+	return p.Provider.SearchMovie(ctx, req)
+}
+
+func newMovieProvider() *providertest.Provider {
+	return &providertest.Provider{
+		Movies: []providertest.Movie{{ID: 1, Name: "The Matrix", Year: 1999}},
+	}
+}
+
+func TestMemoizeConcurrentSearches(t *testing.T) {
+	p := &blockingProvider{
+		Provider: newMovieProvider(),
+		started:  make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+	m := provider.Memoize(p)
+
+	const searches = 10
+	responses := make([]provider.ResponseMovie, searches)
+	errs := make([]error, searches)
+	var wg sync.WaitGroup
+	for i := range searches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Queries differing by case and spaces are the same search.
+			query := "The Matrix"
+			if i%2 == 1 {
+				query = " the  matrix"
+			}
+			responses[i], _, errs[i] = m.SearchMovie(t.Context(), provider.Request{Query: query, Year: 1999})
+		}()
+	}
+
+	<-p.started
+	close(p.release)
+	wg.Wait()
+
+	if n := p.Searches(); n != 1 {
+		t.Errorf("expected identical searches to query the provider once, got %d", n)
+	}
+	for i := range searches {
+		if errs[i] != nil {
+			t.Fatalf("search %d failed: %v", i, errs[i])
+		}
+		if responses[i] != responses[0] {
+			t.Errorf("expected search %d to share the response of the first search", i)
+		}
+	}
+
+	// Other searches are not shared.
+	_, _, err := m.SearchMovie(t.Context(), provider.Request{Query: "The Matrix", Year: 2003})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = m.SearchTV(t.Context(), provider.Request{Query: "The Matrix", Year: 1999})
+	if !errors.Is(err, provider.ErrNoResult) {
+		t.Fatalf("expected no show to be found, got %v", err)
+	}
+	if n := p.Searches(); n != 3 {
+		t.Errorf("expected different searches to query the provider, got %d searches", n)
+	}
+}
+
+func TestMemoizeErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		searches int
+	}{
+		// Transient failures are searched again.
+		{name: "transient", err: errors.New("connection reset"), searches: 2},
+		// Searches without result are not.
+		{name: "no result", err: fmt.Errorf("search failed: %w", provider.ErrNoResult), searches: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newMovieProvider()
+			m := provider.Memoize(p)
+			req := provider.Request{Query: "The Matrix", Year: 1999}
+
+			p.SetError(tc.err)
+			_, _, err := m.SearchMovie(t.Context(), req)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+
+			p.SetError(nil)
+			_, _, err = m.SearchMovie(t.Context(), req)
+			if tc.searches == 2 && err != nil {
+				t.Errorf("expected search to succeed once the failure is gone, got %v", err)
+			}
+			if tc.searches == 1 && !errors.Is(err, tc.err) {
+				t.Errorf("expected cached error %v, got %v", tc.err, err)
+			}
+			if n := p.Searches(); n != tc.searches {
+				t.Errorf("expected %d searches, got %d", tc.searches, n)
+			}
+		})
+	}
+}
@@ -2,14 +2,16 @@
 package provider
 
 import (
+	"context"
+
 	"github.com/spf13/pflag"
 )
 
 // Interface is the interface that all providers must implement.
 type Interface interface {
 	Name() string
-	SearchMovie(Request) (ResponseMovie, float64, error)
-	SearchTV(Request) (ResponseTV, float64, error)
+	SearchMovie(context.Context, Request) (ResponseMovie, float64, error)
+	SearchTV(context.Context, Request) (ResponseTV, float64, error)
 }
 
 // NewFunc is a function that creates a new provider instance.
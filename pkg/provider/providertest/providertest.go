@@ -0,0 +1,284 @@
+// Package providertest provides an in-memory provider, for tests which need to search media without a metadata service.
+package providertest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/util"
+)
+
+// Name is the name of the provider.
+const Name = "test"
+
+// Movie is a movie known by the provider.
+type Movie struct {
+	ID   int
+	Name string
+	Year int
+}
+
+// Show is a TV show known by the provider.
+type Show struct {
+	ID   int
+	Name string
+	Year int
+	// Seasons holds the number of episodes of each season, indexed by season number, season 0 holds the specials.
+	// Episodes are named "Episode <number>".
+	Seasons []int
+}
+
+// Provider searches the movies and shows it knows, every one of them is a result of every search of its type.
+// Results are scored with util.BestMatch, like real providers do.
+type Provider struct {
+	Movies []Movie
+	Shows  []Show
+
+	mu sync.Mutex
+	// err is returned by searches instead of their results, when set.
+	err      error
+	searches int
+}
+
+// SetError makes the next searches fail with err, nil restores them.
+func (p *Provider) SetError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+// Searches returns the number of searches done so far.
+func (p *Provider) Searches() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.searches
+}
+
+// search counts a search and returns the error it should fail with, if any.
+func (p *Provider) search() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.searches++
+	return p.err
+}
+
+func (p *Provider) Name() string {
+	return Name
+}
+
+func (p *Provider) SearchMovie(_ context.Context, req provider.Request) (provider.ResponseMovie, float64, error) {
+	err := p.search()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, score, err := util.BestMatch(req, p.Movies, func(m Movie, req provider.Request) (*MovieResponse, error) {
+		return NewMovieResponse(m, req), nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return resp, score, nil
+}
+
+func (p *Provider) SearchTV(_ context.Context, req provider.Request) (provider.ResponseTV, float64, error) {
+	err := p.search()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, score, err := util.BestMatch(req, p.Shows, func(s Show, req provider.Request) (*TVResponse, error) {
+		return NewTVResponse(s, req), nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return resp, score, nil
+}
+
+// MovieResponse is a movie found by the provider.
+type MovieResponse struct {
+	Movie
+
+	// mu guards the candidates, the response is shared by the entries searching for it.
+	mu sync.Mutex
+
+	provider.ResponseBaseMovie
+}
+
+// NewMovieResponse returns m as found by req.
+func NewMovieResponse(m Movie, req provider.Request) *MovieResponse {
+	r := &MovieResponse{
+		Movie:             m,
+		ResponseBaseMovie: provider.NewResponseBaseMovie(),
+	}
+	r.SetRequest(req)
+
+	return r
+}
+
+func (r *MovieResponse) GetID() int          { return r.ID }
+func (r *MovieResponse) GetProvider() string { return Name }
+func (r *MovieResponse) GetName() string     { return r.Name }
+func (r *MovieResponse) GetDate() time.Time  { return yearDate(r.Year) }
+func (r *MovieResponse) GetPopularity() int  { return 50 }
+
+func (r *MovieResponse) GetCandidates() []provider.Candidate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ResponseBaseMovie.GetCandidates()
+}
+
+func (r *MovieResponse) SetCandidates(candidates []provider.Candidate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ResponseBaseMovie.SetCandidates(candidates)
+}
+
+func (r *MovieResponse) InLanguage(context.Context, provider.Request) (provider.Response, error) {
+	return r, nil
+}
+
+// TVResponse is a TV show found by the provider.
+type TVResponse struct {
+	Show
+
+	seasons []provider.ResponseTVSeason
+
+	// mu guards the candidates, the response is shared by the entries searching for it.
+	mu sync.Mutex
+
+	provider.ResponseBaseTV
+}
+
+// NewTVResponse returns s as found by req, along with its seasons and episodes.
+func NewTVResponse(s Show, req provider.Request) *TVResponse {
+	r := &TVResponse{
+		Show:           s,
+		ResponseBaseTV: provider.NewResponseBaseTV(),
+	}
+	r.SetRequest(req)
+
+	for number, episodes := range s.Seasons {
+		season := &SeasonResponse{
+			show:                 r,
+			number:               number,
+			ResponseBaseTVSeason: provider.NewResponseBaseTVSeason(),
+		}
+		for e := 1; e <= episodes; e++ {
+			season.episodes = append(season.episodes, &EpisodeResponse{
+				season:                season,
+				number:                e,
+				ResponseBaseTVEpisode: provider.NewResponseBaseTVEpisode(),
+			})
+		}
+		r.seasons = append(r.seasons, season)
+	}
+
+	return r
+}
+
+func (r *TVResponse) GetID() int          { return r.ID }
+func (r *TVResponse) GetProvider() string { return Name }
+func (r *TVResponse) GetName() string     { return r.Name }
+func (r *TVResponse) GetDate() time.Time  { return yearDate(r.Year) }
+func (r *TVResponse) GetPopularity() int  { return 50 }
+func (r *TVResponse) GetSeasonCount() int { return len(r.seasons) }
+
+func (r *TVResponse) GetCandidates() []provider.Candidate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ResponseBaseTV.GetCandidates()
+}
+
+func (r *TVResponse) SetCandidates(candidates []provider.Candidate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ResponseBaseTV.SetCandidates(candidates)
+}
+
+func (r *TVResponse) InLanguage(context.Context, provider.Request) (provider.Response, error) {
+	return r, nil
+}
+
+func (r *TVResponse) GetSeason(_ context.Context, number int) (provider.ResponseTVSeason, error) {
+	if number < 0 || number >= len(r.seasons) {
+		return nil, fmt.Errorf("season %d not found: %w", number, provider.ErrNoResult)
+	}
+
+	return r.seasons[number], nil
+}
+
+func (r *TVResponse) GetSeasons(context.Context) ([]provider.ResponseTVSeason, error) {
+	return r.seasons, nil
+}
+
+// SeasonResponse is a season of a TV show found by the provider.
+type SeasonResponse struct {
+	show     *TVResponse
+	number   int
+	episodes []provider.ResponseTVEpisode
+
+	provider.ResponseBaseTVSeason
+}
+
+func (r *SeasonResponse) GetID() int                                { return r.show.ID*100 + r.number }
+func (r *SeasonResponse) GetProvider() string                       { return Name }
+func (r *SeasonResponse) GetName() string                           { return fmt.Sprintf("Season %d", r.number) }
+func (r *SeasonResponse) GetDate() time.Time                        { return yearDate(r.show.Year + r.number) }
+func (r *SeasonResponse) GetPopularity() int                        { return 50 }
+func (r *SeasonResponse) GetShow() provider.ResponseTV              { return r.show }
+func (r *SeasonResponse) GetSeasonNumber() int                      { return r.number }
+func (r *SeasonResponse) GetEpisodes() []provider.ResponseTVEpisode { return r.episodes }
+
+func (r *SeasonResponse) InLanguage(context.Context, provider.Request) (provider.Response, error) {
+	return r, nil
+}
+
+func (r *SeasonResponse) GetEpisode(number int) (provider.ResponseTVEpisode, error) {
+	if number < 1 || number > len(r.episodes) {
+		return nil, fmt.Errorf("episode %d of season %d not found: %w", number, r.number, provider.ErrNoResult)
+	}
+
+	return r.episodes[number-1], nil
+}
+
+// EpisodeResponse is an episode of a TV show found by the provider.
+type EpisodeResponse struct {
+	season *SeasonResponse
+	number int
+
+	provider.ResponseBaseTVEpisode
+}
+
+func (r *EpisodeResponse) GetID() int                           { return r.season.GetID()*100 + r.number }
+func (r *EpisodeResponse) GetProvider() string                  { return Name }
+func (r *EpisodeResponse) GetName() string                      { return fmt.Sprintf("Episode %d", r.number) }
+func (r *EpisodeResponse) GetDate() time.Time                   { return r.season.GetDate() }
+func (r *EpisodeResponse) GetPopularity() int                   { return 50 }
+func (r *EpisodeResponse) GetEpisodeNumber() int                { return r.number }
+func (r *EpisodeResponse) GetSeason() provider.ResponseTVSeason { return r.season }
+
+func (r *EpisodeResponse) InLanguage(context.Context, provider.Request) (provider.Response, error) {
+	return r, nil
+}
+
+// yearDate returns the first day of year, or the zero time when year is 0 for undated media.
+func yearDate(year int) time.Time {
+	if year == 0 {
+		return time.Time{}
+	}
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+}
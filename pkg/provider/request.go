@@ -5,6 +5,7 @@ import (
 	"io/fs"
 
 	"github.com/TheoBrigitte/evansky/pkg/parser"
+	"github.com/TheoBrigitte/evansky/pkg/score"
 )
 
 // Request represents a search request to a provider.
@@ -17,6 +18,18 @@ type Request struct {
 	Entry               fs.DirEntry
 
 	Response Response
+	// Parent is the request of the parent entry, when its media was found.
+	Parent *Request
+	// Scorer scores the search results, score.Default when nil.
+	Scorer score.Scorer
+}
+
+// GetScorer returns the scorer of the request, or the default one.
+func (r Request) GetScorer() score.Scorer {
+	if r.Scorer != nil {
+		return r.Scorer
+	}
+	return score.Default
 }
 
 func (r Request) String() string {
@@ -1,6 +1,9 @@
 package provider
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Response represents a search response from a provider.
 type Response interface {
@@ -10,7 +13,7 @@ type Response interface {
 	GetName() string
 	GetDate() time.Time
 	GetPopularity() int
-	InLanguage(Request) (Response, error)
+	InLanguage(context.Context, Request) (Response, error)
 
 	ResponseBase
 }
@@ -24,8 +27,12 @@ type ResponseMovie interface {
 type ResponseTV interface {
 	Response
 
-	GetSeason(int) (ResponseTVSeason, error)
-	GetSeasons() []ResponseTVSeason
+	// GetSeason returns the season with the given number, seasons are loaded on demand.
+	GetSeason(context.Context, int) (ResponseTVSeason, error)
+	// GetSeasons returns every season of the show, specials included.
+	GetSeasons(context.Context) ([]ResponseTVSeason, error)
+	// GetSeasonCount returns the number of seasons of the show, it is only known once a season was loaded.
+	GetSeasonCount() int
 
 	ResponseBaseTV
 }
@@ -53,10 +60,13 @@ type ResponseTVEpisode interface {
 type ResponseBase interface {
 	GetRequest() *Request
 	SetRequest(Request)
+	GetCandidates() []Candidate
+	SetCandidates([]Candidate)
 }
 
 type responseBase struct {
-	Request *Request
+	Request    *Request
+	Candidates []Candidate
 }
 
 func newResponseBase() *responseBase {
@@ -71,6 +81,15 @@ func (r *responseBase) SetRequest(req Request) {
 	r.Request = &req
 }
 
+// GetCandidates returns every candidate considered by the search which returned this response, best first.
+func (r *responseBase) GetCandidates() []Candidate {
+	return r.Candidates
+}
+
+func (r *responseBase) SetCandidates(candidates []Candidate) {
+	r.Candidates = candidates
+}
+
 type ResponseBaseMovie interface {
 	ResponseBase
 	movie()
@@ -2,8 +2,8 @@
 package tmdb
 
 import (
-	"context" //nolint:gosec
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/TheoBrigitte/evansky/pkg/httpcache"
+	"github.com/TheoBrigitte/evansky/pkg/httpretry"
 	"github.com/TheoBrigitte/evansky/pkg/provider"
 )
 
@@ -34,14 +35,32 @@ func New(flags *pflag.FlagSet) (provider.Interface, error) {
 		cd = filepath.Join(dir, defaultCacheDir)
 	}
 
+	if rateLimit < 0 {
+		return nil, fmt.Errorf("--%s must not be negative: %v", rateLimitFlag, rateLimit)
+	}
+	if maxRetries < 0 {
+		return nil, fmt.Errorf("--%s must not be negative: %d", maxRetriesFlag, maxRetries)
+	}
+	if searchMaxPages < 1 {
+		return nil, fmt.Errorf("--%s must be at least 1: %d", searchMaxPagesFlag, searchMaxPages)
+	}
+
+	// Cached responses are served before being throttled, only actual calls to the API are rate limited and retried.
+	transport := httpretry.New(http.DefaultTransport, httpretry.Options{
+		RequestsPerSecond: rateLimit,
+		Burst:             max(int(rateLimit), 1),
+		MaxRetries:        maxRetries,
+	})
+
 	tmdbClient := tmdb.New(apiKey, metadata.WithHTTPClient(httpcache.New(httpcache.Options{
-		CacheDir: cd,
-		TTL:      cacheTTL,
+		CacheDir:  cd,
+		TTL:       cacheTTL,
+		Transport: transport,
 	})))
 
 	c := &Client{
-		client: tmdbClient,
-		ctx:    context.TODO(),
+		client:         tmdbClient,
+		searchMaxPages: searchMaxPages,
 	}
 
 	return c, nil
@@ -50,3 +69,12 @@ func New(flags *pflag.FlagSet) (provider.Interface, error) {
 func (c *Client) Name() string {
 	return name
 }
+
+// classify marks transient errors of API calls as retryable, other errors are permanent.
+func classify(err error) error {
+	if httpretry.IsTransient(err) {
+		return fmt.Errorf("%w: %w", provider.ErrRetryable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,107 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/golusoris/goenvoy/metadata/video/tmdb"
+)
+
+// fakeAPI serves search results by query and page, and details named after their language.
+// Shows have seasons 0 to 2, each holding a single episode.
+type fakeAPI struct {
+	movies map[string][][]tmdb.MovieResult
+	shows  map[string][][]tmdb.TVResult
+
+	mu sync.Mutex
+	// calls counts the calls by their description
+	calls map[string]int
+	// searches lists the searched queries and pages in order
+	searches []string
+}
+
+func newFakeClient(api *fakeAPI, maxPages int) *Client {
+	return &Client{client: api, searchMaxPages: maxPages}
+}
+
+func (f *fakeAPI) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[call]++
+}
+
+// count returns the number of calls described by call.
+func (f *fakeAPI) count(call string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls[call]
+}
+
+func (f *fakeAPI) search(query string, page int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.searches = append(f.searches, fmt.Sprintf("%s #%d", query, page))
+}
+
+// pageOf returns the page numbered page of pages, empty past the last one.
+func pageOf[E any](pages [][]E, page int) *tmdb.PaginatedResult[E] {
+	if page > len(pages) {
+		return &tmdb.PaginatedResult[E]{Page: page}
+	}
+
+	return &tmdb.PaginatedResult[E]{Page: page, Results: pages[page-1]}
+}
+
+func (f *fakeAPI) SearchMovies(_ context.Context, query, _ string, page int) (*tmdb.PaginatedResult[tmdb.MovieResult], error) {
+	f.search(query, page)
+	return pageOf(f.movies[query], page), nil
+}
+
+func (f *fakeAPI) SearchTV(_ context.Context, query, _ string, page int) (*tmdb.PaginatedResult[tmdb.TVResult], error) {
+	f.search(query, page)
+	return pageOf(f.shows[query], page), nil
+}
+
+func (f *fakeAPI) GetMovie(_ context.Context, id int, language string) (*tmdb.MovieDetails, error) {
+	f.record(fmt.Sprintf("movie %d %s", id, language))
+	return &tmdb.MovieDetails{ID: int64(id), Title: "Movie " + language, ReleaseDate: "1999-03-31"}, nil
+}
+
+func (f *fakeAPI) GetTV(_ context.Context, id int, language string) (*tmdb.TVDetails, error) {
+	f.record(fmt.Sprintf("tv %d %s", id, language))
+	return &tmdb.TVDetails{
+		ID:           int64(id),
+		Name:         "Show " + language,
+		FirstAirDate: "2017-12-01",
+		Seasons:      []tmdb.SeasonSummary{{SeasonNumber: 0}, {SeasonNumber: 1}, {SeasonNumber: 2}},
+	}, nil
+}
+
+func (f *fakeAPI) GetTVSeason(_ context.Context, id, season int, language string) (*tmdb.SeasonDetails, error) {
+	f.record(fmt.Sprintf("season %d %d %s", id, season, language))
+	return &tmdb.SeasonDetails{
+		ID:           int64(id*100 + season),
+		Name:         fmt.Sprintf("Season %d %s", season, language),
+		SeasonNumber: season,
+		Episodes: []tmdb.Episode{
+			{ID: int64((id*100+season)*100 + 1), Name: "Episode " + language, EpisodeNumber: 1, SeasonNumber: season},
+		},
+	}, nil
+}
+
+func (f *fakeAPI) GetTVEpisode(_ context.Context, id, season, episode int, language string) (*tmdb.EpisodeDetails, error) {
+	f.record(fmt.Sprintf("episode %d %d %d %s", id, season, episode, language))
+	return &tmdb.EpisodeDetails{
+		ID:            int64((id*100+season)*100 + episode),
+		Name:          "Episode " + language,
+		EpisodeNumber: episode,
+		SeasonNumber:  season,
+	}, nil
+}
@@ -16,13 +16,19 @@ const (
 
 // Flag variables
 var (
-	apiKey       string
-	apiKeyEnvVar string
-	cacheTTL     time.Duration
-
-	apiKeyFlag       = "tmdb-api-key"         //nolint:gosec
-	apiKeyEnvVarFlag = "tmdb-api-key-env-var" //nolint:gosec
-	cacheDir         string
+	apiKey         string
+	apiKeyEnvVar   string
+	cacheTTL       time.Duration
+	maxRetries     int
+	rateLimit      float64
+	searchMaxPages int
+
+	apiKeyFlag         = "tmdb-api-key"         //nolint:gosec
+	apiKeyEnvVarFlag   = "tmdb-api-key-env-var" //nolint:gosec
+	cacheDir           string
+	maxRetriesFlag     = "tmdb-max-retries"
+	rateLimitFlag      = "tmdb-rate-limit"
+	searchMaxPagesFlag = "tmdb-search-max-pages"
 )
 
 // Provider returns the tmdb provider with its flags
@@ -32,6 +38,9 @@ func Provider() provider.Provider {
 	flags.StringVar(&apiKeyEnvVar, apiKeyEnvVarFlag, "TMDB_API_KEY", "tmdb api key environment variable name")
 	flags.StringVar(&cacheDir, "tmdb-cache-dir", "", "cache directory (default: $XDG_CACHE_HOME/evansky/tmdb or $HOME/.cache/evansky/tmdb)")
 	flags.DurationVar(&cacheTTL, "tmdb-client-cache-ttl", 60*time.Second, "tmdb http client cache ttl, 0 to disable")
+	flags.IntVar(&maxRetries, maxRetriesFlag, 5, "number of retries of tmdb api calls failing on transient errors (rate limited, server errors, timeouts)")
+	flags.Float64Var(&rateLimit, rateLimitFlag, 40, "maximum number of tmdb api calls per second, 0 to disable")
+	flags.IntVar(&searchMaxPages, searchMaxPagesFlag, 3, "maximum number of search result pages fetched when the first ones hold no confident match")
 
 	return provider.Provider{
 		Name:  name,
@@ -1,86 +1,200 @@
 package tmdb
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/golusoris/goenvoy/metadata/video/tmdb"
 	"github.com/rs/zerolog/log"
 
 	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/rewrite"
 	"github.com/TheoBrigitte/evansky/pkg/util"
 )
 
+const (
+	// confidentTitleScore is the title similarity above which a search result is considered the searched media.
+	confidentTitleScore = 0.9
+	// searchMaxRewrites is the number of query rewrites searched at most by a lookup, each fetches a single page.
+	searchMaxRewrites = 4
+)
+
 // SearchMovie search for movies using query and year (if provided).
 // see: https://developer.themoviedb.org/reference/search-movie
-func (c *Client) SearchMovie(req provider.Request) (provider.ResponseMovie, float64, error) {
-	movies, err := c.searchMovie(req)
+func (c *Client) SearchMovie(ctx context.Context, req provider.Request) (provider.ResponseMovie, float64, error) {
+	confident := func(req provider.Request, m tmdb.MovieResult) bool {
+		return isConfident(req, m.Title, m.ReleaseDate)
+	}
+
+	movies, scoreReq, err := searchQueries(ctx, req, c.searchMaxPages, c.searchMovie, confident)
 	if err != nil {
-		if !errors.Is(err, provider.ErrNoResult) {
-			return nil, 0, err
-		}
+		return nil, 0, err
+	}
 
-		// Try again without year and language filters
-		req.Year = 0
-		req.QueryLanguage = ""
-		movies, err = c.searchMovie(req)
-		if err != nil {
-			return nil, 0, err
-		}
+	resp, score, err := util.BestMatch(scoreReq, movies, c.newMovieResponse)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	resp, score := util.BestMatch(req, movies.Results, c.newMovieResponse)
-	return resp, score, err
+	return resp, score, nil
 }
 
-func (c *Client) searchMovie(req provider.Request) (*tmdb.PaginatedResult[tmdb.MovieResult], error) {
+func (c *Client) searchMovie(ctx context.Context, req provider.Request, page int) ([]tmdb.MovieResult, error) {
 	query := buildAdditionalQuery(req)
-	log.Debug().Str("query", query).Any("language", req.QueryLanguage).Msg("searching movie")
-	movies, err := c.client.SearchMovies(c.ctx, query, req.QueryLanguage, 1)
+	log.Debug().Str("query", query).Any("language", req.QueryLanguage).Int("page", page).Msg("searching movie")
+	movies, err := c.client.SearchMovies(ctx, query, req.QueryLanguage, page)
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
+	provider.Trace(ctx, "%s: searching movies %q (language %q), page %d: %d results", name, query, req.QueryLanguage, page, len(movies.Results))
 	if len(movies.Results) <= 0 {
 		return nil, provider.ErrNoResult
 	}
 
-	return movies, nil
+	return movies.Results, nil
 }
 
 // SearchTV search for tv shows using query and year (if provided).
 // see: https://developer.themoviedb.org/reference/search-tv
-func (c *Client) SearchTV(req provider.Request) (provider.ResponseTV, float64, error) {
-	tvshows, err := c.searchTV(req)
+func (c *Client) SearchTV(ctx context.Context, req provider.Request) (provider.ResponseTV, float64, error) {
+	confident := func(req provider.Request, t tmdb.TVResult) bool {
+		return isConfident(req, t.Name, t.FirstAirDate)
+	}
+
+	tvshows, scoreReq, err := searchQueries(ctx, req, c.searchMaxPages, c.searchTV, confident)
 	if err != nil {
-		if !errors.Is(err, provider.ErrNoResult) {
-			return nil, 0, err
-		}
+		return nil, 0, err
+	}
 
-		// Try again without year and language filters
-		req.Year = 0
-		req.QueryLanguage = ""
-		tvshows, err = c.searchTV(req)
-		if err != nil {
-			return nil, 0, err
-		}
+	resp, score, err := util.BestMatch(scoreReq, tvshows, c.newTVResponse)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	resp, score := util.BestMatch(req, tvshows.Results, c.newTVResponse)
-	return resp, score, err
+	return resp, score, nil
 }
 
-func (c *Client) searchTV(req provider.Request) (*tmdb.PaginatedResult[tmdb.TVResult], error) {
+func (c *Client) searchTV(ctx context.Context, req provider.Request, page int) ([]tmdb.TVResult, error) {
 	query := buildAdditionalQuery(req)
-	log.Debug().Str("query", query).Any("language", req.QueryLanguage).Msg("searching tv")
-	tvshows, err := c.client.SearchTV(c.ctx, query, req.QueryLanguage, 1)
+	log.Debug().Str("query", query).Any("language", req.QueryLanguage).Int("page", page).Msg("searching tv")
+	tvshows, err := c.client.SearchTV(ctx, query, req.QueryLanguage, page)
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
+	provider.Trace(ctx, "%s: searching TV shows %q (language %q), page %d: %d results", name, query, req.QueryLanguage, page, len(tvshows.Results))
 	if len(tvshows.Results) <= 0 {
 		return nil, provider.ErrNoResult
 	}
 
-	return tvshows, nil
+	return tvshows.Results, nil
+}
+
+// searchQueries returns the results of search for req, trying the query rewrites in turn until one gets a confident result.
+// Only the first page of a rewrite is fetched, and at most searchMaxRewrites rewrites are searched.
+// When none does, the results of the original query are kept, or else the results of the first rewrite having some.
+// Without any result, the search is tried again without year and language filters.
+// The request to score the results against is returned along with them: it always holds the original query,
+// as a rewrite may drop a part of the title, and it has no year when the filters were dropped.
+func searchQueries[E any](ctx context.Context, req provider.Request, maxPages int, search func(context.Context, provider.Request, int) ([]E, error), confident func(provider.Request, E) bool) ([]E, provider.Request, error) {
+	original := func(e E) bool { return confident(req, e) }
+	results, err := searchPages(ctx, req, maxPages, search, original)
+	if err != nil && !errors.Is(err, provider.ErrNoResult) {
+		return nil, req, err
+	}
+	if slices.ContainsFunc(results, original) {
+		return results, req, nil
+	}
+
+	for i, q := range rewrite.Expand(req.Query) {
+		if i >= searchMaxRewrites {
+			log.Debug().Str("query", req.Query).Msgf("tried %d query rewrites, giving up", searchMaxRewrites)
+			break
+		}
+
+		rewritten := req
+		rewritten.Query = q.Query
+		log.Debug().Str("rewrite", q.Rule).Str("query", q.Query).Msg("trying query rewrite")
+
+		// Results are compared to the original query too, as a rewrite may drop a part of the title.
+		match := func(e E) bool { return confident(req, e) || confident(rewritten, e) }
+		rewrittenResults, err := searchPages(ctx, rewritten, 1, search, match)
+		if err != nil {
+			if errors.Is(err, provider.ErrNoResult) {
+				continue
+			}
+			return nil, req, err
+		}
+
+		if slices.ContainsFunc(rewrittenResults, match) {
+			log.Debug().Str("rewrite", q.Rule).Str("query", q.Query).Str("original_query", req.Query).Msg("query rewrite found a confident match")
+			provider.Trace(ctx, "%s: query rewrite %s found a confident match with %q", name, q.Rule, q.Query)
+			return rewrittenResults, req, nil
+		}
+		if results == nil {
+			results = rewrittenResults
+		}
+	}
+
+	if results != nil {
+		return results, req, nil
+	}
+
+	// Try again without year and language filters
+	provider.Trace(ctx, "%s: no result, searching again without year and language", name)
+	req.Year = 0
+	req.QueryLanguage = ""
+	results, err = searchPages(ctx, req, maxPages, search, original)
+	if err != nil {
+		return nil, req, err
+	}
+
+	return results, req, nil
+}
+
+// searchPages returns the results of search, fetching the next pages until one holds a confident result.
+// At most maxPages pages are fetched, fewer when the results run out.
+// When a confident result is found past the first page, only its page is returned:
+// results are ranked by their position, those of the previous pages hold no confident result and would outrank it.
+func searchPages[E any](ctx context.Context, req provider.Request, maxPages int, search func(context.Context, provider.Request, int) ([]E, error), confident func(E) bool) ([]E, error) {
+	var results []E
+	for page := 1; page <= max(maxPages, 1); page++ {
+		pageResults, err := search(ctx, req, page)
+		if err != nil {
+			if page > 1 && errors.Is(err, provider.ErrNoResult) {
+				// No more results, keep the previous pages.
+				break
+			}
+			return nil, err
+		}
+
+		if slices.ContainsFunc(pageResults, confident) {
+			return pageResults, nil
+		}
+
+		results = append(results, pageResults...)
+	}
+
+	return results, nil
+}
+
+// isConfident returns whether a result titled title and released on date matches req closely enough to stop searching further pages.
+// The year is only compared when req has one.
+func isConfident(req provider.Request, title, date string) bool {
+	titleScore := req.GetScorer().Similarity(req.Query, title)
+	if titleScore < confidentTitleScore {
+		return false
+	}
+
+	if req.Year == 0 {
+		return true
+	}
+
+	// Dates are formatted as "2006-01-02".
+	year, _, _ := strings.Cut(date, "-")
+	return year == strconv.Itoa(req.Year)
 }
 
 func buildAdditionalQuery(req provider.Request) string {
@@ -0,0 +1,212 @@
+package tmdb
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/golusoris/goenvoy/metadata/video/tmdb"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+)
+
+// movies returns search results titled titles, released in 1999.
+func movies(titles ...string) []tmdb.MovieResult {
+	results := make([]tmdb.MovieResult, 0, len(titles))
+	for i, title := range titles {
+		results = append(results, tmdb.MovieResult{ID: int64(i + 1), Title: title, ReleaseDate: "1999-03-31", Popularity: 10})
+	}
+
+	return results
+}
+
+// titlesOf returns the titles of results.
+func titlesOf(results []tmdb.MovieResult) []string {
+	var titles []string
+	for _, r := range results {
+		titles = append(titles, r.Title)
+	}
+
+	return titles
+}
+
+// confidentMovie is the confident function of movie searches.
+func confidentMovie(req provider.Request, m tmdb.MovieResult) bool {
+	return isConfident(req, m.Title, m.ReleaseDate)
+}
+
+func TestSearchPages(t *testing.T) {
+	pages := [][]tmdb.MovieResult{
+		movies("Speed", "Heat"),
+		movies("The Animatrix", "The Matrix"),
+		movies("Point Break"),
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		maxPages int
+		results  []string
+		searches []string
+	}{
+		// Pages are fetched until one holds a confident result, only its page is kept.
+		{name: "confident on second page", query: "The Matrix", maxPages: 3, results: []string{"The Animatrix", "The Matrix"}, searches: []string{"The Matrix #1", "The Matrix #2"}},
+		{name: "confident on first page", query: "Heat", maxPages: 3, results: []string{"Speed", "Heat"}, searches: []string{"Heat #1"}},
+		// Without confident result, every page up to the maximum is kept.
+		{name: "not confident", query: "Matrix Trilogy", maxPages: 2, results: []string{"Speed", "Heat", "The Animatrix", "The Matrix"}, searches: []string{"Matrix Trilogy #1", "Matrix Trilogy #2"}},
+		{name: "single page", query: "Matrix Trilogy", maxPages: 0, results: []string{"Speed", "Heat"}, searches: []string{"Matrix Trilogy #1"}},
+		// Pages stop when the results run out.
+		{name: "results run out", query: "Matrix Trilogy", maxPages: 5, results: titlesOf(slices.Concat(pages...)), searches: []string{"Matrix Trilogy #1", "Matrix Trilogy #2", "Matrix Trilogy #3", "Matrix Trilogy #4"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &fakeAPI{movies: map[string][][]tmdb.MovieResult{tc.query: pages}}
+			c := newFakeClient(api, tc.maxPages)
+			req := provider.Request{Query: tc.query}
+
+			results, err := searchPages(t.Context(), req, tc.maxPages, c.searchMovie, func(m tmdb.MovieResult) bool { return confidentMovie(req, m) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := titlesOf(results); !slices.Equal(result, tc.results) {
+				t.Errorf("For input '%s', expected '%q' but got '%q'", tc.query, tc.results, result)
+			}
+			if !slices.Equal(api.searches, tc.searches) {
+				t.Errorf("For input '%s', expected searches '%q' but got '%q'", tc.query, tc.searches, api.searches)
+			}
+		})
+	}
+
+	api := &fakeAPI{}
+	_, err := searchPages(t.Context(), provider.Request{Query: "Heat"}, 3, newFakeClient(api, 3).searchMovie, func(tmdb.MovieResult) bool { return false })
+	if !errors.Is(err, provider.ErrNoResult) {
+		t.Errorf("expected no result, got %v", err)
+	}
+	if len(api.searches) != 1 {
+		t.Errorf("expected a single search without result, got %q", api.searches)
+	}
+}
+
+func TestSearchMoviePageRanks(t *testing.T) {
+	api := &fakeAPI{movies: map[string][][]tmdb.MovieResult{
+		"The Matrix": {
+			movies("Speed", "Heat", "Point Break"),
+			movies("The Matrix", "The Animatrix"),
+		},
+	}}
+	c := newFakeClient(api, 3)
+
+	resp, _, err := c.SearchMovie(t.Context(), provider.Request{Query: "The Matrix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetName() != "The Matrix" {
+		t.Errorf("expected the confident result to be the match, got %q", resp.GetName())
+	}
+
+	// Results of the confident page are ranked on their own, previous pages would outrank them.
+	candidates := resp.GetCandidates()
+	if len(candidates) != 2 {
+		t.Fatalf("expected the candidates of the confident page only, got %d", len(candidates))
+	}
+	c0, ok := provider.CandidateOf(resp)
+	if !ok || c0.Breakdown.Year != 0 {
+		t.Errorf("expected the match to be ranked first of its page, got %+v", c0.Breakdown)
+	}
+}
+
+func TestSearchQueries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      provider.Request
+		movies   map[string][][]tmdb.MovieResult
+		results  []string
+		searches []string
+		scoreReq provider.Request
+	}{
+		{
+			name:     "original confident",
+			req:      provider.Request{Query: "The Matrix"},
+			movies:   map[string][][]tmdb.MovieResult{"The Matrix": {movies("The Matrix")}},
+			results:  []string{"The Matrix"},
+			searches: []string{"The Matrix #1"},
+			scoreReq: provider.Request{Query: "The Matrix"},
+		},
+		{
+			// Rewrites are searched in turn until one is confident, they are scored against the original query.
+			name: "rewrite confident",
+			req:  provider.Request{Query: "Rocky II & 3"},
+			movies: map[string][][]tmdb.MovieResult{
+				"Rocky II & 3":       {movies("Rocky Balboa")},
+				"Rocky 2 & 3":        {movies("Rocky V")},
+				"Rocky II & III":     {movies("Rocky II & III")},
+				"Rocky II and 3":     {movies("Rocky II and 3")},
+				"Rocky II & 3 Extra": {movies("Rocky II & 3 Extra")},
+			},
+			results:  []string{"Rocky II & III"},
+			searches: []string{"Rocky II & 3 #1", "Rocky II & 3 #2", "Rocky 2 & 3 #1", "Rocky II & III #1"},
+			scoreReq: provider.Request{Query: "Rocky II & 3"},
+		},
+		{
+			// Without confident rewrite, the results of the original query are kept.
+			name: "original kept",
+			req:  provider.Request{Query: "The Rocky II & 3 - Crème"},
+			movies: map[string][][]tmdb.MovieResult{
+				"The Rocky II & 3 - Crème": {movies("Creed")},
+				"Rocky II & 3 - Crème":     {movies("Rocky V")},
+				// The subtitle rewrite is past the rewrites limit, it is not searched.
+				"The Rocky II & 3": {movies("The Rocky II & 3")},
+			},
+			results: []string{"Creed"},
+			searches: []string{
+				"The Rocky II & 3 - Crème #1", "The Rocky II & 3 - Crème #2",
+				"Rocky II & 3 - Crème #1", "The Rocky 2 & 3 - Crème #1", "The Rocky II & III - Crème #1", "The Rocky II and 3 - Crème #1",
+			},
+			scoreReq: provider.Request{Query: "The Rocky II & 3 - Crème"},
+		},
+		{
+			// Without result of the original query, the results of the first rewrite having some are kept.
+			name: "first rewrite kept",
+			req:  provider.Request{Query: "Rocky II & 3"},
+			movies: map[string][][]tmdb.MovieResult{
+				"Rocky II & III": {movies("Creed")},
+				"Rocky II and 3": {movies("Rocky V")},
+			},
+			results:  []string{"Creed"},
+			searches: []string{"Rocky II & 3 #1", "Rocky 2 & 3 #1", "Rocky II & III #1", "Rocky II and 3 #1"},
+			scoreReq: provider.Request{Query: "Rocky II & 3"},
+		},
+		{
+			// Without any result, the search is tried again without year and language.
+			name:     "filters dropped",
+			req:      provider.Request{Query: "Heat", Year: 1995, QueryLanguage: "fr"},
+			movies:   map[string][][]tmdb.MovieResult{"Heat": {movies("Heat")}},
+			results:  []string{"Heat"},
+			searches: []string{"Heat 1995 #1", "Heat #1"},
+			scoreReq: provider.Request{Query: "Heat"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &fakeAPI{movies: tc.movies}
+			c := newFakeClient(api, 2)
+
+			results, scoreReq, err := searchQueries(t.Context(), tc.req, 2, c.searchMovie, confidentMovie)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := titlesOf(results); !slices.Equal(result, tc.results) {
+				t.Errorf("For input '%s', expected '%q' but got '%q'", tc.req.Query, tc.results, result)
+			}
+			if !slices.Equal(api.searches, tc.searches) {
+				t.Errorf("For input '%s', expected searches '%q' but got '%q'", tc.req.Query, tc.searches, api.searches)
+			}
+			if scoreReq.Query != tc.scoreReq.Query || scoreReq.Year != tc.scoreReq.Year || scoreReq.QueryLanguage != tc.scoreReq.QueryLanguage {
+				t.Errorf("For input '%s', expected score request '%s' but got '%s'", tc.req.Query, fmt.Sprint(tc.scoreReq), fmt.Sprint(scoreReq))
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package tmdb
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/golusoris/goenvoy/metadata/video/tmdb"
@@ -10,9 +12,19 @@ import (
 )
 
 type movieResponse struct {
-	*movie
-	multi  map[string]*movie
-	client *Client
+	// current is the movie in the language of the last InLanguage call, read through get
+	current *movie
+	multi   map[string]*movie
+	client  *Client
+
+	// mu guards current, multi and the candidates, as responses are shared by concurrent scans
+	mu sync.Mutex
+
+	// candidates are kept here, as the movie is swapped by InLanguage
+	candidates []provider.Candidate
+
+	// ResponseBaseMovie marks the response as a movie, its request is the one of the current movie
+	provider.ResponseBaseMovie
 }
 
 type movie struct {
@@ -29,11 +41,12 @@ func (c *Client) newMovieResponse(result tmdb.MovieResult, req provider.Request)
 	}
 
 	r := &movieResponse{
-		movie:  m,
-		client: c,
+		current:           m,
+		client:            c,
+		ResponseBaseMovie: provider.NewResponseBaseMovie(),
 	}
 	r.multi = map[string]*movie{
-		req.QueryLanguage: r.movie,
+		req.QueryLanguage: m,
 	}
 
 	return r, nil
@@ -78,14 +91,67 @@ func (r movie) GetProvider() string {
 	return name
 }
 
-func (m *movieResponse) InLanguage(req provider.Request) (provider.Response, error) {
+// get returns the movie in the language of the last InLanguage call.
+func (m *movieResponse) get() *movie {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current
+}
+
+func (m *movieResponse) GetID() int {
+	return m.get().GetID()
+}
+
+func (m *movieResponse) GetName() string {
+	return m.get().GetName()
+}
+
+func (m *movieResponse) GetDate() time.Time {
+	return m.get().GetDate()
+}
+
+func (m *movieResponse) GetPopularity() int {
+	return m.get().GetPopularity()
+}
+
+func (m *movieResponse) GetProvider() string {
+	return m.get().GetProvider()
+}
+
+func (m *movieResponse) GetRequest() *provider.Request {
+	return m.get().GetRequest()
+}
+
+func (m *movieResponse) SetRequest(req provider.Request) {
+	m.get().SetRequest(req)
+}
+
+func (m *movieResponse) GetCandidates() []provider.Candidate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.candidates
+}
+
+func (m *movieResponse) SetCandidates(candidates []provider.Candidate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.candidates = candidates
+}
+
+func (m *movieResponse) InLanguage(ctx context.Context, req provider.Request) (provider.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if r, ok := m.multi[req.DestinationLanguage]; ok {
-		m.movie = r
+		m.current = r
 	} else {
 		languageQuery := buildLanguageQuery(req.DestinationLanguage)
-		details, err := m.client.client.GetMovie(m.client.ctx, m.GetID(), languageQuery)
+		details, err := m.client.client.GetMovie(ctx, m.current.GetID(), languageQuery)
 		if err != nil {
-			return nil, err
+			return nil, classify(err)
 		}
 
 		// TODO: fetch the movie details in newMovie, so we can also store the full details here
@@ -111,7 +177,7 @@ func (m *movieResponse) InLanguage(req provider.Request) (provider.Response, err
 		}
 
 		m.multi[req.DestinationLanguage] = movie
-		m.movie = movie
+		m.current = movie
 	}
 
 	return m, nil
@@ -1,7 +1,10 @@
 package tmdb
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/golusoris/goenvoy/metadata/video/tmdb"
@@ -12,27 +15,43 @@ import (
 )
 
 type tvResponse struct {
-	*tv
-	multi  map[string]*tv
-	client *Client
+	// current is the show in the language of the last InLanguage call, read through get
+	current *tv
+	multi   map[string]*tv
+	client  *Client
+
+	// mu guards current, multi and the candidates
+	mu sync.Mutex
+
+	// candidates are kept here, as the tv is swapped by InLanguage
+	candidates []provider.Candidate
+
+	// ResponseBaseTV marks the response as a show, its request is the one of the current show
+	provider.ResponseBaseTV
 }
 
 type tv struct {
 	result       tmdb.TVResult
 	firstAirDate time.Time
-	// Language indexed seasons cache
-	seasons []provider.ResponseTVSeason
+
+	// seasonsMu guards the seasons, which are loaded on demand, once for the language of the show
+	seasonsMu sync.Mutex
+	// seasonNumbers lists the seasons of the show, nil until loaded
+	seasonNumbers []int
+	// seasons caches the loaded seasons by number
+	seasons map[int]provider.ResponseTVSeason
 
 	provider.ResponseBaseTV
 }
 
 func (c *Client) newTVResponse(result tmdb.TVResult, req provider.Request) (*tvResponse, error) {
 	m := &tvResponse{
-		multi:  make(map[string]*tv),
-		client: c,
+		multi:          make(map[string]*tv),
+		client:         c,
+		ResponseBaseTV: provider.NewResponseBaseTV(),
 	}
 
-	err := m.newTv(result, req)
+	err := m.newTv(result, nil, req)
 	if err != nil {
 		return nil, err
 	}
@@ -40,12 +59,17 @@ func (c *Client) newTVResponse(result tmdb.TVResult, req provider.Request) (*tvR
 	return m, nil
 }
 
-func (m *tvResponse) newTv(result tmdb.TVResult, req provider.Request) error {
-	m.tv = &tv{
+// newTv sets the show from a search result, seasons are loaded on demand.
+// seasonNumbers lists the seasons of the show when already known, nil otherwise.
+// m.mu must be held, unless m is not shared yet.
+func (m *tvResponse) newTv(result tmdb.TVResult, seasonNumbers []int, req provider.Request) error {
+	t := &tv{
 		result:         result,
+		seasonNumbers:  seasonNumbers,
+		seasons:        make(map[int]provider.ResponseTVSeason),
 		ResponseBaseTV: provider.NewResponseBaseTV(),
 	}
-	m.SetRequest(req)
+	t.SetRequest(req)
 
 	if result.FirstAirDate != "" {
 		// log.Debug().Msg("parsing tv first air date: " + result.FirstAirDate)
@@ -54,80 +78,204 @@ func (m *tvResponse) newTv(result tmdb.TVResult, req provider.Request) error {
 		if err != nil {
 			return err
 		}
-		m.firstAirDate = firstAirDate
+		t.firstAirDate = firstAirDate
 	}
 
-	languageQuery := buildLanguageQuery(req.DestinationLanguage)
-	resp, err := m.client.client.GetTV(m.client.ctx, m.GetID(), languageQuery)
-	if err != nil {
-		return err
-	}
-
-	seasons := make([]provider.ResponseTVSeason, 0, len(resp.Seasons))
-	for _, s := range resp.Seasons {
-		season, err := m.client.client.GetTVSeason(m.client.ctx, m.GetID(), s.SeasonNumber, languageQuery)
-		if err != nil {
-			return err
-		}
-
-		r, err := m.client.newTVSeasonResponse(*season, m, req)
-		if err != nil {
-			return err
-		}
-		seasons = append(seasons, r)
-	}
-	m.seasons = seasons
-	log.Debug().Msgf("TV show %d seasons loaded: %d", m.GetID(), len(m.seasons))
-	m.multi[req.DestinationLanguage] = m.tv
+	m.current = t
+	m.multi[req.DestinationLanguage] = t
 
 	return nil
 }
 
-func (r tv) GetID() int {
+func (r *tv) GetID() int {
 	return int(r.result.ID)
 }
 
-func (r tv) GetName() string {
+func (r *tv) GetName() string {
 	return r.result.Name
 }
 
-func (r tv) GetDate() time.Time {
+func (r *tv) GetDate() time.Time {
 	return r.firstAirDate
 }
 
-func (r tv) GetPopularity() int {
+func (r *tv) GetPopularity() int {
 	return util.ComputePopularity(r.result.Popularity, r.result.VoteAverage, r.result.VoteCount)
 }
 
-func (r tv) GetProvider() string {
+func (r *tv) GetProvider() string {
 	return name
 }
 
-func (r *tv) GetSeasons() []provider.ResponseTVSeason {
+// GetSeasonCount returns the number of seasons of the show, specials included.
+// It is 0 until a season was loaded.
+func (r *tv) GetSeasonCount() int {
+	r.seasonsMu.Lock()
+	defer r.seasonsMu.Unlock()
+
+	return len(r.seasonNumbers)
+}
+
+// get returns the show in the language of the last InLanguage call.
+func (m *tvResponse) get() *tv {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current
+}
+
+func (m *tvResponse) GetID() int {
+	return m.get().GetID()
+}
+
+func (m *tvResponse) GetName() string {
+	return m.get().GetName()
+}
+
+func (m *tvResponse) GetDate() time.Time {
+	return m.get().GetDate()
+}
+
+func (m *tvResponse) GetPopularity() int {
+	return m.get().GetPopularity()
+}
+
+func (m *tvResponse) GetProvider() string {
+	return m.get().GetProvider()
+}
+
+func (m *tvResponse) GetSeasonCount() int {
+	return m.get().GetSeasonCount()
+}
+
+func (m *tvResponse) GetRequest() *provider.Request {
+	return m.get().GetRequest()
+}
+
+func (m *tvResponse) SetRequest(req provider.Request) {
+	m.get().SetRequest(req)
+}
+
+// GetSeasons returns every season of the show, loading the missing ones.
+func (m *tvResponse) GetSeasons(ctx context.Context) ([]provider.ResponseTVSeason, error) {
+	t := m.get()
+	t.seasonsMu.Lock()
+	defer t.seasonsMu.Unlock()
+
+	err := m.loadSeasonNumbers(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	seasons := make([]provider.ResponseTVSeason, 0, len(t.seasonNumbers))
+	for _, number := range t.seasonNumbers {
+		season, err := m.loadSeason(ctx, t, number)
+		if err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, season)
+	}
 	// slog.Debug("get seasons", "show_id", r.GetID(), "seasons", len(r.seasons))
-	return r.seasons
+
+	return seasons, nil
 }
 
-func (r tv) GetSeason(seasonNumber int) (provider.ResponseTVSeason, error) {
+// GetSeason returns the season numbered seasonNumber, loading it when missing.
+func (m *tvResponse) GetSeason(ctx context.Context, seasonNumber int) (provider.ResponseTVSeason, error) {
 	// slog.Debug("get season", "show_id", r.GetID(), "season_number", seasonNumber)
+	t := m.get()
+	t.seasonsMu.Lock()
+	defer t.seasonsMu.Unlock()
 
-	for _, s := range r.GetSeasons() {
-		if s.GetSeasonNumber() == seasonNumber {
-			return s, nil
-		}
+	err := m.loadSeasonNumbers(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(t.seasonNumbers, seasonNumber) {
+		return nil, fmt.Errorf("%w for season %d of show %d", provider.ErrNoResult, seasonNumber, t.GetID())
+	}
+
+	return m.loadSeason(ctx, t, seasonNumber)
+}
+
+// loadSeasonNumbers fetches the list of seasons of t, unless already known.
+// t.seasonsMu must be held.
+func (m *tvResponse) loadSeasonNumbers(ctx context.Context, t *tv) error {
+	if t.seasonNumbers != nil {
+		return nil
+	}
+
+	languageQuery := buildLanguageQuery(t.GetRequest().DestinationLanguage)
+	details, err := m.client.client.GetTV(ctx, t.GetID(), languageQuery)
+	if err != nil {
+		return classify(err)
+	}
+
+	t.seasonNumbers = seasonNumbersOf(details)
+	log.Debug().Msgf("TV show %d seasons listed: %d", t.GetID(), len(t.seasonNumbers))
+
+	return nil
+}
+
+// loadSeason fetches the season numbered seasonNumber of t along with its episodes, unless already loaded.
+// t.seasonsMu must be held.
+func (m *tvResponse) loadSeason(ctx context.Context, t *tv, seasonNumber int) (provider.ResponseTVSeason, error) {
+	if season, ok := t.seasons[seasonNumber]; ok {
+		return season, nil
+	}
+
+	req := *t.GetRequest()
+	languageQuery := buildLanguageQuery(req.DestinationLanguage)
+	details, err := m.client.client.GetTVSeason(ctx, t.GetID(), seasonNumber, languageQuery)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	season, err := m.client.newTVSeasonResponse(*details, m, req)
+	if err != nil {
+		return nil, err
+	}
+	t.seasons[seasonNumber] = season
+
+	return season, nil
+}
+
+// seasonNumbersOf returns the numbers of the seasons listed in details, never nil.
+func seasonNumbersOf(details *tmdb.TVDetails) []int {
+	numbers := make([]int, 0, len(details.Seasons))
+	for _, s := range details.Seasons {
+		numbers = append(numbers, s.SeasonNumber)
 	}
 
-	return nil, fmt.Errorf("season %d not found for show %d", seasonNumber, r.GetID())
+	return numbers
+}
+
+func (m *tvResponse) GetCandidates() []provider.Candidate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.candidates
 }
 
-func (m *tvResponse) InLanguage(req provider.Request) (provider.Response, error) {
+func (m *tvResponse) SetCandidates(candidates []provider.Candidate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.candidates = candidates
+}
+
+func (m *tvResponse) InLanguage(ctx context.Context, req provider.Request) (provider.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if r, ok := m.multi[req.DestinationLanguage]; ok {
-		m.tv = r
+		m.current = r
 	} else {
 		languageQuery := buildLanguageQuery(req.DestinationLanguage)
-		details, err := m.client.client.GetTV(m.client.ctx, m.GetID(), languageQuery)
+		details, err := m.client.client.GetTV(ctx, m.current.GetID(), languageQuery)
 		if err != nil {
-			return nil, err
+			return nil, classify(err)
 		}
 
 		result := tmdb.TVResult{
@@ -145,7 +293,8 @@ func (m *tvResponse) InLanguage(req provider.Request) (provider.Response, error)
 			VoteCount:        details.VoteCount,
 		}
 
-		err = m.newTv(result, req)
+		// Details already list the seasons, they are kept to spare a call.
+		err = m.newTv(result, seasonNumbersOf(details), req)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,168 @@
+package tmdb
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golusoris/goenvoy/metadata/video/tmdb"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+)
+
+func TestInLanguageConcurrentReads(t *testing.T) {
+	c := newFakeClient(&fakeAPI{}, 1)
+	req := provider.Request{Query: "Dark", DestinationLanguage: "en"}
+	show, err := c.newTVResponse(tmdb.TVResult{ID: 1, Name: "Show en", FirstAirDate: "2017-12-01"}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	movie, err := c.newMovieResponse(tmdb.MovieResult{ID: 2, Title: "Movie en", ReleaseDate: "1999-03-31"}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Responses are shared by concurrent scans, which may ask for other languages while others read them.
+	var wg sync.WaitGroup
+	for i := range 8 {
+		language := []string{"en", "de"}[i%2]
+		wg.Go(func() {
+			_, err := show.InLanguage(t.Context(), provider.Request{DestinationLanguage: language})
+			if err != nil {
+				t.Error(err)
+			}
+			_, err = movie.InLanguage(t.Context(), provider.Request{DestinationLanguage: language})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+		wg.Go(func() {
+			if name := show.GetName(); !strings.HasPrefix(name, "Show ") || show.GetID() != 1 || show.GetDate().Year() != 2017 {
+				t.Errorf("unexpected show %d %q", show.GetID(), name)
+			}
+			if name := movie.GetName(); !strings.HasPrefix(name, "Movie ") || movie.GetID() != 2 || movie.GetDate().Year() != 1999 {
+				t.Errorf("unexpected movie %d %q", movie.GetID(), name)
+			}
+			_ = show.GetRequest()
+			_ = movie.GetRequest()
+			_ = show.GetPopularity() + movie.GetPopularity() + show.GetSeasonCount()
+
+			season, err := show.GetSeason(t.Context(), 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			episode, err := season.GetEpisode(1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, err = episode.InLanguage(t.Context(), provider.Request{DestinationLanguage: language})
+			if err != nil {
+				t.Error(err)
+			}
+			if episode.GetSeason().GetShow().GetID() != 1 || episode.GetEpisodeNumber() != 1 {
+				t.Errorf("unexpected episode %d of show %d", episode.GetEpisodeNumber(), episode.GetSeason().GetShow().GetID())
+			}
+
+			_, err = show.GetSeasons(t.Context())
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	_, err = show.InLanguage(t.Context(), provider.Request{DestinationLanguage: "de"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := show.GetName(); name != "Show de" {
+		t.Errorf("expected show in the last requested language, got %q", name)
+	}
+	if language := show.GetRequest().DestinationLanguage; language != "de" {
+		t.Errorf("expected request of the last requested language, got %q", language)
+	}
+}
+
+func TestSeasonsLoadedOnce(t *testing.T) {
+	api := &fakeAPI{}
+	c := newFakeClient(api, 1)
+	show, err := c.newTVResponse(tmdb.TVResult{ID: 1, Name: "Show en"}, provider.Request{DestinationLanguage: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Go(func() {
+			season, err := show.GetSeason(t.Context(), i%3)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if season.GetSeasonNumber() != i%3 {
+				t.Errorf("expected season %d, got %d", i%3, season.GetSeasonNumber())
+			}
+		})
+		wg.Go(func() {
+			seasons, err := show.GetSeasons(t.Context())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(seasons) != 3 {
+				t.Errorf("expected 3 seasons, got %d", len(seasons))
+			}
+		})
+	}
+	wg.Wait()
+
+	// Seasons are listed and loaded once, whatever the number of concurrent calls.
+	expected := map[string]int{"tv 1 en": 1, "season 1 0 en": 1, "season 1 1 en": 1, "season 1 2 en": 1}
+	for call, n := range expected {
+		if count := api.count(call); count != n {
+			t.Errorf("expected %d call %q, got %d", n, call, count)
+		}
+	}
+	if n := show.GetSeasonCount(); n != 3 {
+		t.Errorf("expected 3 seasons, got %d", n)
+	}
+
+	// Missing seasons are not loaded.
+	_, err = show.GetSeason(t.Context(), 3)
+	if !errors.Is(err, provider.ErrNoResult) {
+		t.Errorf("expected missing season to have no result, got %v", err)
+	}
+	if count := api.count("season 1 3 en"); count != 0 {
+		t.Errorf("expected missing season not to be loaded, got %d calls", count)
+	}
+
+	// Seasons of another language are loaded once for it, its details already list them.
+	_, err = show.InLanguage(t.Context(), provider.Request{DestinationLanguage: "de"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 4 {
+		wg.Go(func() {
+			_, err := show.GetSeason(t.Context(), 1)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	for call, n := range map[string]int{"tv 1 de": 1, "season 1 1 de": 1, "tv 1 en": 1} {
+		if count := api.count(call); count != n {
+			t.Errorf("expected %d call %q, got %d", n, call, count)
+		}
+	}
+	season, err := show.GetSeason(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := season.GetName(); name != "Season 1 de" {
+		t.Errorf("expected season in the language of the show, got %q", name)
+	}
+}
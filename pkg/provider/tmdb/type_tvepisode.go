@@ -2,6 +2,7 @@ package tmdb
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/golusoris/goenvoy/metadata/video/tmdb"
@@ -11,10 +12,16 @@ import (
 )
 
 type tvEpisodeResponse struct {
-	*tvEpisode
-	multi  map[string]*tvEpisode
-	client *tmdb.Client
-	ctx    context.Context
+	// current is the episode in the language of the last InLanguage call, read through get
+	current *tvEpisode
+	multi   map[string]*tvEpisode
+	client  *Client
+
+	// mu guards current and multi
+	mu sync.Mutex
+
+	// ResponseBaseTVEpisode marks the response as an episode, its request is the one of the current episode
+	provider.ResponseBaseTVEpisode
 }
 
 type tvEpisode struct {
@@ -32,12 +39,12 @@ func (c *Client) newTVEpisodeResponse(result tmdb.EpisodeDetails, season provide
 	}
 
 	m := &tvEpisodeResponse{
-		tvEpisode: t,
-		client:    c.client,
-		ctx:       c.ctx,
+		current:               t,
+		client:                c,
+		ResponseBaseTVEpisode: provider.NewResponseBaseTVEpisode(),
 	}
 	m.multi = map[string]*tvEpisode{
-		req.DestinationLanguage: m.tvEpisode,
+		req.DestinationLanguage: t,
 	}
 
 	return m, nil
@@ -91,23 +98,71 @@ func (r tvEpisode) GetProvider() string {
 	return name
 }
 
-func (m *tvEpisodeResponse) InLanguage(req provider.Request) (provider.Response, error) {
+// get returns the episode in the language of the last InLanguage call.
+func (m *tvEpisodeResponse) get() *tvEpisode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current
+}
+
+func (m *tvEpisodeResponse) GetID() int {
+	return m.get().GetID()
+}
+
+func (m *tvEpisodeResponse) GetName() string {
+	return m.get().GetName()
+}
+
+func (m *tvEpisodeResponse) GetDate() time.Time {
+	return m.get().GetDate()
+}
+
+func (m *tvEpisodeResponse) GetPopularity() int {
+	return m.get().GetPopularity()
+}
+
+func (m *tvEpisodeResponse) GetEpisodeNumber() int {
+	return m.get().GetEpisodeNumber()
+}
+
+func (m *tvEpisodeResponse) GetSeason() provider.ResponseTVSeason {
+	return m.get().GetSeason()
+}
+
+func (m *tvEpisodeResponse) GetProvider() string {
+	return m.get().GetProvider()
+}
+
+func (m *tvEpisodeResponse) GetRequest() *provider.Request {
+	return m.get().GetRequest()
+}
+
+func (m *tvEpisodeResponse) SetRequest(req provider.Request) {
+	m.get().SetRequest(req)
+}
+
+func (m *tvEpisodeResponse) InLanguage(ctx context.Context, req provider.Request) (provider.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if r, ok := m.multi[req.DestinationLanguage]; ok {
-		m.tvEpisode = r
+		m.current = r
 	} else {
 		languageQuery := buildLanguageQuery(req.DestinationLanguage)
-		details, err := m.client.GetTVEpisode(m.ctx, m.GetSeason().GetShow().GetID(), m.GetSeason().GetSeasonNumber(), m.GetID(), languageQuery)
+		season := m.current.GetSeason()
+		details, err := m.client.client.GetTVEpisode(ctx, season.GetShow().GetID(), season.GetSeasonNumber(), m.current.GetEpisodeNumber(), languageQuery)
 		if err != nil {
-			return nil, err
+			return nil, classify(err)
 		}
 
-		e, err := newTVEpisode(*details, m.GetSeason(), req)
+		e, err := newTVEpisode(*details, season, req)
 		if err != nil {
 			return nil, err
 		}
 
 		m.multi[req.DestinationLanguage] = e
-		m.tvEpisode = e
+		m.current = e
 	}
 
 	return m, nil
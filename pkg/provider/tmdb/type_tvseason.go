@@ -1,7 +1,9 @@
 package tmdb
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golusoris/goenvoy/metadata/video/tmdb"
@@ -11,9 +13,16 @@ import (
 )
 
 type tvSeasonResponse struct {
-	*tvSeason
-	multi  map[string]*tvSeason
-	client *Client
+	// current is the season in the language of the last InLanguage call, read through get
+	current *tvSeason
+	multi   map[string]*tvSeason
+	client  *Client
+
+	// mu guards current and multi
+	mu sync.Mutex
+
+	// ResponseBaseTVSeason marks the response as a season, its request is the one of the current season
+	provider.ResponseBaseTVSeason
 }
 
 type tvSeason struct {
@@ -26,10 +35,12 @@ type tvSeason struct {
 	provider.ResponseBaseTVSeason
 }
 
+// newTVSeasonResponse returns the season described by result, along with its episodes.
 func (c *Client) newTVSeasonResponse(result tmdb.SeasonDetails, show provider.ResponseTV, req provider.Request) (*tvSeasonResponse, error) {
 	m := &tvSeasonResponse{
-		multi:  make(map[string]*tvSeason),
-		client: c,
+		multi:                make(map[string]*tvSeason),
+		client:               c,
+		ResponseBaseTVSeason: provider.NewResponseBaseTVSeason(),
 	}
 
 	err := m.init(result, show, req)
@@ -40,13 +51,15 @@ func (c *Client) newTVSeasonResponse(result tmdb.SeasonDetails, show provider.Re
 	return m, nil
 }
 
+// init sets the season from its details, which already hold its episodes.
+// m.mu must be held, unless m is not shared yet.
 func (m *tvSeasonResponse) init(result tmdb.SeasonDetails, show provider.ResponseTV, req provider.Request) error {
-	m.tvSeason = &tvSeason{
+	s := &tvSeason{
 		result:               result,
 		show:                 show,
 		ResponseBaseTVSeason: provider.NewResponseBaseTVSeason(),
 	}
-	m.SetRequest(req)
+	s.SetRequest(req)
 
 	if result.AirDate != "" {
 		// log.Debug().Msgf("parsing tv season air date: %s", result.AirDate)
@@ -55,17 +68,11 @@ func (m *tvSeasonResponse) init(result tmdb.SeasonDetails, show provider.Respons
 		if err != nil {
 			return err
 		}
-		m.airDate = airDate
+		s.airDate = airDate
 	}
 
-	languageQuery := buildLanguageQuery(req.DestinationLanguage)
-	season, err := m.client.client.GetTVSeason(m.client.ctx, show.GetID(), result.SeasonNumber, languageQuery)
-	if err != nil {
-		return err
-	}
-
-	episodes := make([]provider.ResponseTVEpisode, 0, len(season.Episodes))
-	for _, e := range season.Episodes {
+	episodes := make([]provider.ResponseTVEpisode, 0, len(result.Episodes))
+	for _, e := range result.Episodes {
 		// This is a dirty way to convert an episode to tmdb.TVEpisodeDetails, as season.Episodes has no concrete type.
 		var ed tmdb.EpisodeDetails
 		ed.AirDate = e.AirDate
@@ -85,8 +92,9 @@ func (m *tvSeasonResponse) init(result tmdb.SeasonDetails, show provider.Respons
 		}
 		episodes = append(episodes, episode)
 	}
-	m.episodes = episodes
-	m.multi[req.DestinationLanguage] = m.tvSeason
+	s.episodes = episodes
+	m.current = s
+	m.multi[req.DestinationLanguage] = s
 
 	return nil
 }
@@ -137,17 +145,72 @@ func (r tvSeason) GetEpisode(episodeNumber int) (provider.ResponseTVEpisode, err
 	return nil, fmt.Errorf("%w for episode %d in season %d of show %d", provider.ErrNoResult, episodeNumber, r.result.SeasonNumber, r.show.GetID())
 }
 
-func (m *tvSeasonResponse) InLanguage(req provider.Request) (provider.Response, error) {
+// get returns the season in the language of the last InLanguage call.
+func (m *tvSeasonResponse) get() *tvSeason {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current
+}
+
+func (m *tvSeasonResponse) GetID() int {
+	return m.get().GetID()
+}
+
+func (m *tvSeasonResponse) GetName() string {
+	return m.get().GetName()
+}
+
+func (m *tvSeasonResponse) GetDate() time.Time {
+	return m.get().GetDate()
+}
+
+func (m *tvSeasonResponse) GetProvider() string {
+	return m.get().GetProvider()
+}
+
+func (m *tvSeasonResponse) GetPopularity() int {
+	return m.get().GetPopularity()
+}
+
+func (m *tvSeasonResponse) GetShow() provider.ResponseTV {
+	return m.get().GetShow()
+}
+
+func (m *tvSeasonResponse) GetSeasonNumber() int {
+	return m.get().GetSeasonNumber()
+}
+
+func (m *tvSeasonResponse) GetEpisodes() []provider.ResponseTVEpisode {
+	return m.get().GetEpisodes()
+}
+
+func (m *tvSeasonResponse) GetEpisode(episodeNumber int) (provider.ResponseTVEpisode, error) {
+	return m.get().GetEpisode(episodeNumber)
+}
+
+func (m *tvSeasonResponse) GetRequest() *provider.Request {
+	return m.get().GetRequest()
+}
+
+func (m *tvSeasonResponse) SetRequest(req provider.Request) {
+	m.get().SetRequest(req)
+}
+
+func (m *tvSeasonResponse) InLanguage(ctx context.Context, req provider.Request) (provider.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if r, ok := m.multi[req.DestinationLanguage]; ok {
-		m.tvSeason = r
+		m.current = r
 	} else {
 		languageQuery := buildLanguageQuery(req.DestinationLanguage)
-		details, err := m.client.client.GetTVSeason(m.client.ctx, m.GetShow().GetID(), m.GetSeasonNumber(), languageQuery)
+		details, err := m.client.client.GetTVSeason(ctx, m.current.show.GetID(), m.current.GetSeasonNumber(), languageQuery)
 		if err != nil {
-			return nil, err
+			return nil, classify(err)
 		}
 
-		err = m.init(*details, m.GetShow(), req)
+		err = m.init(*details, m.current.show, req)
 		if err != nil {
 			return nil, err
 		}
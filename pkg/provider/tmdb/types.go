@@ -8,6 +8,18 @@ import (
 
 // Client to communicate with tmdb api.
 type Client struct {
-	client *tmdb.Client
-	ctx    context.Context
+	client api
+
+	// searchMaxPages is the number of result pages fetched by a search at most
+	searchMaxPages int
+}
+
+// api lists the calls made to the tmdb api, it is implemented by tmdb.Client.
+type api interface {
+	SearchMovies(ctx context.Context, query, language string, page int) (*tmdb.PaginatedResult[tmdb.MovieResult], error)
+	SearchTV(ctx context.Context, query, language string, page int) (*tmdb.PaginatedResult[tmdb.TVResult], error)
+	GetMovie(ctx context.Context, id int, language string) (*tmdb.MovieDetails, error)
+	GetTV(ctx context.Context, id int, language string) (*tmdb.TVDetails, error)
+	GetTVSeason(ctx context.Context, id, season int, language string) (*tmdb.SeasonDetails, error)
+	GetTVEpisode(ctx context.Context, id, season, episode int, language string) (*tmdb.EpisodeDetails, error)
 }
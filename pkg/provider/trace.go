@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tracer receives the steps taken to find the media of entries, in order, along with the path of the entry they belong to.
+// It is used to explain how a match was chosen.
+type Tracer func(entry, step string)
+
+type tracerKey struct{}
+
+type traceEntryKey struct{}
+
+// WithTracer returns a copy of ctx in which steps are reported to t.
+func WithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// WithTraceEntry returns a copy of ctx in which steps are reported as belonging to the entry at path.
+func WithTraceEntry(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, traceEntryKey{}, path)
+}
+
+// Trace reports a step to the tracer of ctx, when there is one.
+func Trace(ctx context.Context, format string, args ...any) {
+	t, ok := ctx.Value(tracerKey{}).(Tracer)
+	if !ok {
+		return
+	}
+
+	entry, _ := ctx.Value(traceEntryKey{}).(string)
+	t(entry, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package renamer
+
+// sameDevice reports whether a and b are located on the same filesystem.
+// Device information is not available on this platform, the operating system
+// reports the error when creating the link instead.
+func sameDevice(a, b string) (bool, error) {
+	return true, nil
+}
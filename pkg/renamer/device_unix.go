@@ -0,0 +1,38 @@
+//go:build unix
+
+package renamer
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// sameDevice reports whether a and b are located on the same filesystem.
+func sameDevice(a, b string) (bool, error) {
+	devA, err := device(a)
+	if err != nil {
+		return false, err
+	}
+	devB, err := device(b)
+	if err != nil {
+		return false, err
+	}
+
+	return devA == devB, nil
+}
+
+// device returns the device ID of the filesystem containing path.
+func device(path string) (uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("failed to read device of %q", path)
+	}
+
+	return uint64(stat.Dev), nil //nolint:gosec,unconvert
+}
@@ -6,7 +6,7 @@
 //   - Fetching metadata from configured providers
 //   - Formatting new filenames using customizable formatters
 //   - Creating organized directory structures
-//   - Supporting multiple rename modes (symlink, copy)
+//   - Supporting multiple rename modes (symlink, hardlink, copy, move)
 //
 // The renamer handles duplicate detection, path deduplication, and provides
 // detailed logging of all operations. It supports dry-run mode for previewing
@@ -1,8 +1,14 @@
 package format
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
 	"github.com/TheoBrigitte/evansky/pkg/provider"
 	"github.com/TheoBrigitte/evansky/pkg/source"
+	"github.com/TheoBrigitte/evansky/pkg/source/language"
 )
 
 type Formatter interface {
@@ -12,3 +18,65 @@ type Formatter interface {
 	TVEpisode(provider.ResponseTVEpisode, source.Node) []string
 	FileSuffix(string, source.Node) string
 }
+
+// NewFunc is a function that creates a new formatter instance.
+type NewFunc func(*pflag.FlagSet) (Formatter, error)
+
+// FormatFunc is a function that returns a Format.
+type FormatFunc func() Format
+
+// Format represents a formatter with its name, constructor function, and flags.
+type Format struct {
+	Name  string
+	New   NewFunc
+	Flags *pflag.FlagSet
+}
+
+// languageSuffix appends the normalized language of subtitle files to name.
+func languageSuffix(name string, n source.Node) string {
+	if !n.Entry.IsDir() && n.Type == source.NodeTypeSubtitle {
+		normalizedLang := language.NormalizeLanguage(n.Info.Language)
+		if normalizedLang != "" {
+			return fmt.Sprintf("%s.%s", name, normalizedLang)
+		}
+	}
+
+	return name
+}
+
+// editionSuffix returns the edition of the media as a file name suffix, e.g. " - Director's Cut".
+func editionSuffix(n source.Node) string {
+	if n.Info.Edition == "" {
+		return ""
+	}
+
+	return " - " + n.Info.Edition
+}
+
+// partSuffix returns the part number of multi-part movies as a file name suffix, e.g. " - part1".
+func partSuffix(n source.Node) string {
+	if n.Info.Part == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" - part%d", n.Info.Part)
+}
+
+// episodesOf returns every episode of a multi-episode node, or e alone.
+func episodesOf(e provider.ResponseTVEpisode, n source.Node) []provider.ResponseTVEpisode {
+	if len(n.Episodes) > 1 {
+		return n.Episodes
+	}
+
+	return []provider.ResponseTVEpisode{e}
+}
+
+// episodeNames returns the names of the episodes joined with " & ", e.g. "Pilot & The Return".
+func episodeNames(episodes []provider.ResponseTVEpisode) string {
+	names := make([]string, 0, len(episodes))
+	for _, e := range episodes {
+		names = append(names, e.GetName())
+	}
+
+	return strings.Join(names, " & ")
+}
@@ -0,0 +1,235 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/TheoBrigitte/evansky/pkg/parser"
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+type testCase struct {
+	input    string
+	expected string
+}
+
+var (
+	testMovie = providertest.NewMovieResponse(providertest.Movie{ID: 603, Name: "The Matrix", Year: 1999}, provider.Request{})
+	// testShow has 2 specials and 2 seasons of 10 episodes.
+	testShow = providertest.NewTVResponse(providertest.Show{ID: 70523, Name: "Dark", Year: 2017, Seasons: []int{2, 10, 10}}, provider.Request{})
+)
+
+// mediaNode returns a media file node holding info.
+func mediaNode(info parser.Info) source.Node {
+	return source.Node{Info: info, Type: source.NodeTypeMedia}
+}
+
+// episodeOf returns the episode numbered episode of the season numbered season of testShow.
+func episodeOf(t *testing.T, season, episode int) provider.ResponseTVEpisode {
+	t.Helper()
+
+	s, err := testShow.GetSeason(t.Context(), season)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := s.GetEpisode(episode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+// episodeNode returns the media file node of the episodes numbered episodes of the season numbered season of testShow,
+// along with its first episode.
+func episodeNode(t *testing.T, season int, episodes ...int) (provider.ResponseTVEpisode, source.Node) {
+	t.Helper()
+
+	n := mediaNode(parser.Info{})
+	for _, number := range episodes {
+		n.Episodes = append(n.Episodes, episodeOf(t, season, number))
+	}
+
+	return n.Episodes[0], n
+}
+
+// formatters returns every formatter with its default options, by name.
+func formatters(t *testing.T) map[string]Formatter {
+	t.Helper()
+
+	template, err := NewTemplateFormatter(TemplateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Formatter{
+		"jellyfin": NewJellyfinFormatter(JellyfinOptions{}),
+		"plex":     NewPlexFormatter(),
+		"kodi":     NewKodiFormatter(),
+		"template": template,
+	}
+}
+
+// fileName returns the last component of path.
+func fileName(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	return path[len(path)-1]
+}
+
+func TestEditionSuffix(t *testing.T) {
+	testCases := []testCase{
+		{input: "", expected: ""},
+		{input: "Director's Cut", expected: " - Director's Cut"},
+		{input: "Criterion", expected: " - Criterion"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("editionSuffix_%03d", i), func(t *testing.T) {
+			result := editionSuffix(mediaNode(parser.Info{Edition: tc.input}))
+			if result != tc.expected {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", tc.input, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestEditions(t *testing.T) {
+	// Every version of a movie gets its own file name within the same folder.
+	expected := map[string]struct{ folder, file string }{
+		"jellyfin": {folder: "The Matrix (1999)", file: "The Matrix (1999) - Unrated"},
+		"plex":     {folder: "The Matrix (1999) {test-603}", file: "The Matrix (1999) {edition-Unrated}"},
+		"kodi":     {folder: "The Matrix (1999)", file: "The Matrix (1999) - Unrated"},
+		"template": {folder: "The Matrix (1999)", file: "The Matrix (1999) - Unrated"},
+	}
+
+	for name, f := range formatters(t) {
+		t.Run(name, func(t *testing.T) {
+			plain := f.Movie(testMovie, mediaNode(parser.Info{}))
+			edition := f.Movie(testMovie, mediaNode(parser.Info{Edition: "Unrated"}))
+			if folder := strings.Join(edition[:len(edition)-1], "/"); folder != expected[name].folder || folder != strings.Join(plain[:len(plain)-1], "/") {
+				t.Errorf("For input '%s', expected folder '%s' but got '%s'", name, expected[name].folder, folder)
+			}
+			if result := fileName(edition); result != expected[name].file {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", name, expected[name].file, result)
+			}
+		})
+	}
+}
+
+func TestEpisodesOf(t *testing.T) {
+	first, second := episodeOf(t, 1, 1), episodeOf(t, 1, 2)
+	testCases := []struct {
+		name     string
+		node     source.Node
+		expected string
+	}{
+		{name: "single episode", node: mediaNode(parser.Info{}), expected: "Episode 1"},
+		{name: "single episode listed", node: source.Node{Episodes: []provider.ResponseTVEpisode{first}}, expected: "Episode 1"},
+		{name: "multi-episode", node: source.Node{Episodes: []provider.ResponseTVEpisode{first, second}}, expected: "Episode 1 & Episode 2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := episodeNames(episodesOf(first, tc.node))
+			if result != tc.expected {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", tc.name, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestMultiEpisodes(t *testing.T) {
+	// Multi-episode files are named after their first and last episode, or every one of them.
+	expected := map[string]string{
+		"jellyfin": "Dark - S01E01-E03 - Episode 1 & Episode 2 & Episode 3",
+		"plex":     "Dark (2017) - s01e01-e03 - Episode 1 & Episode 2 & Episode 3",
+		"kodi":     "Dark S01E01E02E03 - Episode 1 & Episode 2 & Episode 3",
+		"template": "Dark - S01E01-E03 - Episode 1 & Episode 2 & Episode 3",
+	}
+
+	e, n := episodeNode(t, 1, 1, 2, 3)
+	for name, f := range formatters(t) {
+		t.Run(name, func(t *testing.T) {
+			result := fileName(f.TVEpisode(e, n))
+			if result != expected[name] {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", name, expected[name], result)
+			}
+		})
+	}
+}
+
+func TestPartSuffix(t *testing.T) {
+	testCases := []struct {
+		part     int
+		expected string
+	}{
+		{part: 0, expected: ""},
+		{part: 1, expected: " - part1"},
+		{part: 12, expected: " - part12"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("partSuffix_%03d", i), func(t *testing.T) {
+			result := partSuffix(mediaNode(parser.Info{Part: tc.part}))
+			if result != tc.expected {
+				t.Errorf("For input '%d', expected '%s' but got '%s'", tc.part, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestParts(t *testing.T) {
+	// Parts of a movie share its folder, and differ by their part number alone.
+	expected := map[string][]string{
+		"jellyfin": {"The Matrix (1999) - part1", "The Matrix (1999) - part2"},
+		"plex":     {"The Matrix (1999) - part1", "The Matrix (1999) - part2"},
+		"kodi":     {"The Matrix (1999)-part1", "The Matrix (1999)-part2"},
+		"template": {"The Matrix (1999) - part1", "The Matrix (1999) - part2"},
+	}
+
+	for name, f := range formatters(t) {
+		t.Run(name, func(t *testing.T) {
+			first := f.Movie(testMovie, mediaNode(parser.Info{Part: 1}))
+			second := f.Movie(testMovie, mediaNode(parser.Info{Part: 2}))
+			if a, b := strings.Join(first[:len(first)-1], "/"), strings.Join(second[:len(second)-1], "/"); a != b {
+				t.Errorf("For input '%s', expected parts to share their folder, got '%s' and '%s'", name, a, b)
+			}
+			for i, result := range []string{fileName(first), fileName(second)} {
+				if result != expected[name][i] {
+					t.Errorf("For input '%s', expected '%s' but got '%s'", name, expected[name][i], result)
+				}
+			}
+		})
+	}
+}
+
+func TestSpecials(t *testing.T) {
+	// Season 0 goes into the specials folder of each media server.
+	expected := map[string]string{
+		"jellyfin": "Dark (2017)/Season 00/Dark - S00E02 - Episode 2",
+		"plex":     "Dark (2017) {test-70523}/Specials/Dark (2017) - s00e02 - Episode 2",
+		"kodi":     "Dark (2017)/Specials/Dark S00E02 - Episode 2",
+		"template": "Dark (2017)/Season 0/Dark - S00E02 - Episode 2",
+	}
+
+	e, n := episodeNode(t, 0, 2)
+	for name, f := range formatters(t) {
+		t.Run(name, func(t *testing.T) {
+			result := strings.Join(f.TVEpisode(e, n), "/")
+			if result != expected[name] {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", name, expected[name], result)
+			}
+			// Seasons and their episodes share the same folder.
+			season := strings.Join(f.TVSeason(e.GetSeason(), n), "/")
+			if !strings.HasPrefix(result, season+"/") {
+				t.Errorf("For input '%s', expected episode '%s' to be within its season '%s'", name, result, season)
+			}
+		})
+	}
+}
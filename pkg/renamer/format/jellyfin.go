@@ -4,35 +4,73 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/spf13/pflag"
+
 	"github.com/TheoBrigitte/evansky/pkg/provider"
 	"github.com/TheoBrigitte/evansky/pkg/source"
-	"github.com/TheoBrigitte/evansky/pkg/source/language"
 )
 
-type JellyfinFormatter struct{}
+// Flag variables
+var (
+	jellyfinProviderIDs bool
+)
+
+type JellyfinFormatter struct {
+	o JellyfinOptions
+}
+
+// JellyfinOptions configures the JellyfinFormatter.
+type JellyfinOptions struct {
+	// ProviderIDs appends provider ID tags to movie and show folder names, e.g. [tmdbid-123]
+	ProviderIDs bool
+}
 
-func NewJellyfinFormatter() JellyfinFormatter {
-	return JellyfinFormatter{}
+// Jellyfin returns the jellyfin formatter with its flags.
+func Jellyfin() Format {
+	flags := pflag.NewFlagSet("jellyfin", pflag.ExitOnError)
+	flags.BoolVar(&jellyfinProviderIDs, "jellyfin-provider-ids", false, "append provider ID tags to movie and show folder names, e.g. [tmdbid-123], to prevent Jellyfin from matching another media")
+
+	return Format{
+		Name: "jellyfin",
+		New: func(*pflag.FlagSet) (Formatter, error) {
+			return NewJellyfinFormatter(JellyfinOptions{
+				ProviderIDs: jellyfinProviderIDs,
+			}), nil
+		},
+		Flags: flags,
+	}
+}
+
+func NewJellyfinFormatter(o JellyfinOptions) JellyfinFormatter {
+	return JellyfinFormatter{
+		o: o,
+	}
 }
 
 // Movie format according to Jellyfin's recommended naming conventions.
+// Editions are appended to the file name, so several versions of a movie sit side by side,
+// followed by the part number of multi-part movies.
 // https://jellyfin.org/docs/general/server/media/movies
 func (f JellyfinFormatter) Movie(m provider.ResponseMovie, n source.Node) []string {
 	movieFormat := fmt.Sprintf("%s (%d)", m.GetName(), m.GetDate().Year())
-	return []string{movieFormat, movieFormat}
+	return []string{f.withProviderIDs(movieFormat, m), movieFormat + editionSuffix(n) + partSuffix(n)}
 }
 
 // TVShow format according to Jellyfin's recommended naming conventions.
 // https://jellyfin.org/docs/general/server/media/shows
 func (f JellyfinFormatter) TVShow(tv provider.ResponseTV, n source.Node) []string {
-	return []string{fmt.Sprintf("%s (%d)", tv.GetName(), tv.GetDate().Year())}
+	return []string{f.withProviderIDs(fmt.Sprintf("%s (%d)", tv.GetName(), tv.GetDate().Year()), tv)}
 }
 
 // TVSeason format according to Jellyfin's recommended naming conventions.
+// Season 0 goes into the Season 00 folder, which Jellyfin shows as Specials.
 func (f JellyfinFormatter) TVSeason(s provider.ResponseTVSeason, n source.Node) []string {
 	showFormat := f.TVShow(s.GetShow(), n)
 
-	seasonPadding := len(strconv.Itoa(len(s.GetShow().GetSeasons())))
+	seasonPadding := len(strconv.Itoa(s.GetShow().GetSeasonCount()))
+	if s.GetSeasonNumber() == 0 {
+		seasonPadding = max(2, seasonPadding)
+	}
 	seasonFormat := fmt.Sprintf("Season %0*d", seasonPadding, s.GetSeasonNumber())
 
 	return append(showFormat, seasonFormat)
@@ -47,21 +85,32 @@ func (f JellyfinFormatter) TVEpisode(e provider.ResponseTVEpisode, n source.Node
 
 	// Padding based on total number of seasons/episodes
 	// e.g. S01E01 for less than 10 seasons/episodes, S001E001 for less than 100 seasons/episodes, etc.
-	seasonPadding := max(2, len(strconv.Itoa(len(show.GetSeasons()))))
+	seasonPadding := max(2, len(strconv.Itoa(show.GetSeasonCount())))
 	episodePadding := max(2, len(strconv.Itoa(len(season.GetEpisodes()))))
 
-	episodeFormat := fmt.Sprintf("%s - S%0*dE%0*d - %s", show.GetName(), seasonPadding, season.GetSeasonNumber(), episodePadding, e.GetEpisodeNumber(), e.GetName())
+	// Multi-episode files are named after the whole range, e.g. S01E01-E02 - Title1 & Title2
+	episodes := episodesOf(e, n)
+	numberFormat := fmt.Sprintf("S%0*dE%0*d", seasonPadding, season.GetSeasonNumber(), episodePadding, e.GetEpisodeNumber())
+	if len(episodes) > 1 {
+		numberFormat = fmt.Sprintf("%s-E%0*d", numberFormat, episodePadding, episodes[len(episodes)-1].GetEpisodeNumber())
+	}
+
+	episodeFormat := fmt.Sprintf("%s - %s - %s", show.GetName(), numberFormat, episodeNames(episodes))
 
 	return append(seasonFormat, episodeFormat)
 }
 
 func (f JellyfinFormatter) FileSuffix(name string, n source.Node) string {
-	if !n.Entry.IsDir() && n.Type == source.NodeTypeSubtitle {
-		normalizedLang := language.NormalizeLanguage(n.Info.Language)
-		if normalizedLang != "" {
-			return fmt.Sprintf("%s.%s", name, normalizedLang)
-		}
+	return languageSuffix(name, n)
+}
+
+// withProviderIDs appends the provider ID tag of r to the folder name, when enabled.
+// Jellyfin uses those tags to pin the match instead of running its own lookup.
+// https://jellyfin.org/docs/general/server/media/movies#provider-identifiers
+func (f JellyfinFormatter) withProviderIDs(name string, r provider.Response) string {
+	if !f.o.ProviderIDs {
+		return name
 	}
 
-	return name
+	return fmt.Sprintf("%s [%sid-%d]", name, r.GetProvider(), r.GetID())
 }
@@ -0,0 +1,86 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/TheoBrigitte/evansky/pkg/parser"
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+)
+
+func TestJellyfinMovie(t *testing.T) {
+	testCases := []struct {
+		providerIDs bool
+		info        parser.Info
+		expected    string
+	}{
+		{info: parser.Info{}, expected: "The Matrix (1999)/The Matrix (1999)"},
+		{info: parser.Info{Edition: "Director's Cut"}, expected: "The Matrix (1999)/The Matrix (1999) - Director's Cut"},
+		{info: parser.Info{Edition: "Extended", Part: 2}, expected: "The Matrix (1999)/The Matrix (1999) - Extended - part2"},
+		// Provider IDs only tag the folder.
+		{providerIDs: true, info: parser.Info{}, expected: "The Matrix (1999) [testid-603]/The Matrix (1999)"},
+		{providerIDs: true, info: parser.Info{Part: 1}, expected: "The Matrix (1999) [testid-603]/The Matrix (1999) - part1"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("jellyfin_movie_%03d", i), func(t *testing.T) {
+			f := NewJellyfinFormatter(JellyfinOptions{ProviderIDs: tc.providerIDs})
+			result := strings.Join(f.Movie(testMovie, mediaNode(tc.info)), "/")
+			if result != tc.expected {
+				t.Errorf("For input '%+v', expected '%s' but got '%s'", tc.info, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestJellyfinTVEpisode(t *testing.T) {
+	testCases := []struct {
+		providerIDs bool
+		season      int
+		episodes    []int
+		expected    string
+	}{
+		{season: 1, episodes: []int{2}, expected: "Dark (2017)/Season 1/Dark - S01E02 - Episode 2"},
+		// Season 0 goes into the Season 00 folder.
+		{season: 0, episodes: []int{1}, expected: "Dark (2017)/Season 00/Dark - S00E01 - Episode 1"},
+		// Multi-episode files are named after the whole range.
+		{season: 1, episodes: []int{3, 4}, expected: "Dark (2017)/Season 1/Dark - S01E03-E04 - Episode 3 & Episode 4"},
+		{season: 2, episodes: []int{8, 9, 10}, expected: "Dark (2017)/Season 2/Dark - S02E08-E10 - Episode 8 & Episode 9 & Episode 10"},
+		// Provider IDs only tag the show folder.
+		{providerIDs: true, season: 1, episodes: []int{2}, expected: "Dark (2017) [testid-70523]/Season 1/Dark - S01E02 - Episode 2"},
+		{providerIDs: true, season: 0, episodes: []int{2}, expected: "Dark (2017) [testid-70523]/Season 00/Dark - S00E02 - Episode 2"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("jellyfin_episode_%03d", i), func(t *testing.T) {
+			f := NewJellyfinFormatter(JellyfinOptions{ProviderIDs: tc.providerIDs})
+			e, n := episodeNode(t, tc.season, tc.episodes...)
+			result := strings.Join(f.TVEpisode(e, n), "/")
+			if result != tc.expected {
+				t.Errorf("For input 'S%dE%v', expected '%s' but got '%s'", tc.season, tc.episodes, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestJellyfinPadding(t *testing.T) {
+	// Numbers are padded after the number of seasons and episodes.
+	show := providertest.NewTVResponse(providertest.Show{ID: 1, Name: "Long", Year: 2000, Seasons: []int{0, 100, 1, 1, 1, 1, 1, 1, 1, 1, 1}}, provider.Request{})
+	season, err := show.GetSeason(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := season.GetEpisode(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewJellyfinFormatter(JellyfinOptions{})
+	result := strings.Join(f.TVEpisode(e, mediaNode(parser.Info{})), "/")
+	expected := "Long (2000)/Season 01/Long - S01E007 - Episode 7"
+	if result != expected {
+		t.Errorf("expected '%s' but got '%s'", expected, result)
+	}
+}
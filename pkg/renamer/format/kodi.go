@@ -0,0 +1,90 @@
+package format
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+type KodiFormatter struct{}
+
+// Kodi returns the kodi formatter with its flags.
+func Kodi() Format {
+	return Format{
+		Name: "kodi",
+		New: func(*pflag.FlagSet) (Formatter, error) {
+			return NewKodiFormatter(), nil
+		},
+	}
+}
+
+func NewKodiFormatter() KodiFormatter {
+	return KodiFormatter{}
+}
+
+// Movie format according to Kodi's recommended naming conventions.
+// Every movie has its own folder, so its artwork and nfo files do not mix with those of other movies.
+// Movie sets get no folder of their own: Kodi groups movies into sets from the information it scrapes, wherever they sit.
+// Editions are appended to the file name, so several versions of a movie sit side by side,
+// followed by the part number of multi-part movies, which Kodi stacks and plays as a single movie.
+// https://kodi.wiki/view/Naming_video_files/Movies
+func (f KodiFormatter) Movie(m provider.ResponseMovie, n source.Node) []string {
+	movieFormat := fmt.Sprintf("%s (%d)", m.GetName(), m.GetDate().Year())
+	return []string{movieFormat, movieFormat + editionSuffix(n) + kodiPartSuffix(n)}
+}
+
+// TVShow format according to Kodi's recommended naming conventions.
+// https://kodi.wiki/view/Naming_video_files/TV_shows
+func (f KodiFormatter) TVShow(tv provider.ResponseTV, n source.Node) []string {
+	return []string{fmt.Sprintf("%s (%d)", tv.GetName(), tv.GetDate().Year())}
+}
+
+// TVSeason format according to Kodi's recommended naming conventions.
+// Season 0 goes into the Specials folder.
+func (f KodiFormatter) TVSeason(s provider.ResponseTVSeason, n source.Node) []string {
+	showFormat := f.TVShow(s.GetShow(), n)
+
+	seasonFormat := fmt.Sprintf("Season %02d", s.GetSeasonNumber())
+	if s.GetSeasonNumber() == 0 {
+		seasonFormat = "Specials"
+	}
+
+	return append(showFormat, seasonFormat)
+}
+
+// TVEpisode format according to Kodi's recommended naming conventions.
+func (f KodiFormatter) TVEpisode(e provider.ResponseTVEpisode, n source.Node) []string {
+	seasonFormat := f.TVSeason(e.GetSeason(), n)
+
+	season := e.GetSeason()
+	show := season.GetShow()
+
+	// Multi-episode files list every episode number, e.g. S01E01E02
+	episodes := episodesOf(e, n)
+	numberFormat := fmt.Sprintf("S%02d", season.GetSeasonNumber())
+	for _, episode := range episodes {
+		numberFormat += fmt.Sprintf("E%02d", episode.GetEpisodeNumber())
+	}
+
+	episodeFormat := fmt.Sprintf("%s %s - %s", show.GetName(), numberFormat, episodeNames(episodes))
+
+	return append(seasonFormat, episodeFormat)
+}
+
+// kodiPartSuffix returns the part number of multi-part movies as a file name suffix, e.g. "-part1".
+// Parts are only stacked when their names match the stacking patterns of Kodi, and differ by the part number alone.
+// https://kodi.wiki/view/Advancedsettings.xml#moviestacking
+func kodiPartSuffix(n source.Node) string {
+	if n.Info.Part == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("-part%d", n.Info.Part)
+}
+
+func (f KodiFormatter) FileSuffix(name string, n source.Node) string {
+	return languageSuffix(name, n)
+}
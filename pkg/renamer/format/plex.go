@@ -0,0 +1,88 @@
+package format
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+type PlexFormatter struct{}
+
+// Plex returns the plex formatter with its flags.
+func Plex() Format {
+	return Format{
+		Name: "plex",
+		New: func(*pflag.FlagSet) (Formatter, error) {
+			return NewPlexFormatter(), nil
+		},
+	}
+}
+
+func NewPlexFormatter() PlexFormatter {
+	return PlexFormatter{}
+}
+
+// Movie format according to Plex's recommended naming conventions.
+// The folder holds the provider ID hint, e.g. {tmdb-123}, which pins the match.
+// Editions are appended to the file name, e.g. {edition-Director's Cut}, followed by the part number of multi-part movies.
+// https://support.plex.tv/articles/naming-and-organizing-your-movie-media-files/
+func (f PlexFormatter) Movie(m provider.ResponseMovie, n source.Node) []string {
+	movieFormat := fmt.Sprintf("%s (%d)", m.GetName(), m.GetDate().Year())
+
+	fileFormat := movieFormat
+	if n.Info.Edition != "" {
+		fileFormat = fmt.Sprintf("%s {edition-%s}", movieFormat, n.Info.Edition)
+	}
+
+	return []string{fmt.Sprintf("%s %s", movieFormat, plexHint(m)), fileFormat + partSuffix(n)}
+}
+
+// TVShow format according to Plex's recommended naming conventions.
+// https://support.plex.tv/articles/naming-and-organizing-your-tv-show-files/
+func (f PlexFormatter) TVShow(tv provider.ResponseTV, n source.Node) []string {
+	return []string{fmt.Sprintf("%s (%d) %s", tv.GetName(), tv.GetDate().Year(), plexHint(tv))}
+}
+
+// TVSeason format according to Plex's recommended naming conventions.
+// Season 0 goes into the Specials folder.
+func (f PlexFormatter) TVSeason(s provider.ResponseTVSeason, n source.Node) []string {
+	showFormat := f.TVShow(s.GetShow(), n)
+
+	seasonFormat := fmt.Sprintf("Season %02d", s.GetSeasonNumber())
+	if s.GetSeasonNumber() == 0 {
+		seasonFormat = "Specials"
+	}
+
+	return append(showFormat, seasonFormat)
+}
+
+// TVEpisode format according to Plex's recommended naming conventions.
+func (f PlexFormatter) TVEpisode(e provider.ResponseTVEpisode, n source.Node) []string {
+	seasonFormat := f.TVSeason(e.GetSeason(), n)
+
+	season := e.GetSeason()
+	show := season.GetShow()
+
+	// Multi-episode files are named after the whole range, e.g. s01e01-e02
+	episodes := episodesOf(e, n)
+	numberFormat := fmt.Sprintf("s%02de%02d", season.GetSeasonNumber(), e.GetEpisodeNumber())
+	if len(episodes) > 1 {
+		numberFormat = fmt.Sprintf("%s-e%02d", numberFormat, episodes[len(episodes)-1].GetEpisodeNumber())
+	}
+
+	episodeFormat := fmt.Sprintf("%s (%d) - %s - %s", show.GetName(), show.GetDate().Year(), numberFormat, episodeNames(episodes))
+
+	return append(seasonFormat, episodeFormat)
+}
+
+func (f PlexFormatter) FileSuffix(name string, n source.Node) string {
+	return languageSuffix(name, n)
+}
+
+// plexHint returns the provider ID hint of r, e.g. {tmdb-123}.
+func plexHint(r provider.Response) string {
+	return fmt.Sprintf("{%s-%d}", r.GetProvider(), r.GetID())
+}
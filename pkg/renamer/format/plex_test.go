@@ -0,0 +1,56 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/TheoBrigitte/evansky/pkg/parser"
+)
+
+func TestPlexMovie(t *testing.T) {
+	testCases := []struct {
+		info     parser.Info
+		expected string
+	}{
+		{info: parser.Info{}, expected: "The Matrix (1999) {test-603}/The Matrix (1999)"},
+		{info: parser.Info{Edition: "Director's Cut"}, expected: "The Matrix (1999) {test-603}/The Matrix (1999) {edition-Director's Cut}"},
+		{info: parser.Info{Part: 1}, expected: "The Matrix (1999) {test-603}/The Matrix (1999) - part1"},
+		{info: parser.Info{Edition: "Extended", Part: 2}, expected: "The Matrix (1999) {test-603}/The Matrix (1999) {edition-Extended} - part2"},
+	}
+
+	f := NewPlexFormatter()
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("plex_movie_%03d", i), func(t *testing.T) {
+			result := strings.Join(f.Movie(testMovie, mediaNode(tc.info)), "/")
+			if result != tc.expected {
+				t.Errorf("For input '%+v', expected '%s' but got '%s'", tc.info, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestPlexTVEpisode(t *testing.T) {
+	testCases := []struct {
+		season   int
+		episodes []int
+		expected string
+	}{
+		{season: 1, episodes: []int{2}, expected: "Dark (2017) {test-70523}/Season 01/Dark (2017) - s01e02 - Episode 2"},
+		// Season 0 goes into the Specials folder.
+		{season: 0, episodes: []int{1}, expected: "Dark (2017) {test-70523}/Specials/Dark (2017) - s00e01 - Episode 1"},
+		{season: 1, episodes: []int{3, 4}, expected: "Dark (2017) {test-70523}/Season 01/Dark (2017) - s01e03-e04 - Episode 3 & Episode 4"},
+		{season: 2, episodes: []int{8, 9, 10}, expected: "Dark (2017) {test-70523}/Season 02/Dark (2017) - s02e08-e10 - Episode 8 & Episode 9 & Episode 10"},
+	}
+
+	f := NewPlexFormatter()
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("plex_episode_%03d", i), func(t *testing.T) {
+			e, n := episodeNode(t, tc.season, tc.episodes...)
+			result := strings.Join(f.TVEpisode(e, n), "/")
+			if result != tc.expected {
+				t.Errorf("For input 'S%dE%v', expected '%s' but got '%s'", tc.season, tc.episodes, tc.expected, result)
+			}
+		})
+	}
+}
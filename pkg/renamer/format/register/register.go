@@ -0,0 +1,89 @@
+package register
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/TheoBrigitte/evansky/pkg/renamer/format"
+)
+
+var (
+	defaultFormat = "jellyfin"
+	chosenFormat  string
+
+	// global FlagSet containing all flags from all registeredFormats.
+	flags = pflag.NewFlagSet("format", pflag.ExitOnError)
+	// registered formats.
+	registeredFormats = map[string]format.NewFunc{}
+)
+
+func init() {
+	mustRegister(format.Jellyfin)
+	mustRegister(format.Plex)
+	mustRegister(format.Kodi)
+	mustRegister(format.Template)
+}
+
+func Initialize(cmd *cobra.Command) {
+	// get sorted list of registered format names.
+	names := slices.Collect(maps.Keys(registeredFormats))
+	slices.Sort(names)
+
+	// add --format flag
+	cmd.PersistentFlags().StringVar(&chosenFormat, "format", defaultFormat, "naming convention of destinations, available: "+strings.Join(names, ","))
+
+	// add formats flags
+	cmd.PersistentFlags().AddFlagSet(flags)
+}
+
+// mustRegister register a new format into the registeredFormats map.
+// It panics if a format with the same name is already registered.
+func mustRegister(f format.FormatFunc) {
+	p := f()
+
+	if p.Name == "" {
+		panic("format name cannot be empty")
+	}
+
+	if _, exists := registeredFormats[p.Name]; exists {
+		panic("format already registered: " + p.Name)
+	}
+
+	if p.Flags != nil {
+		// register all flags from newSet into the global flags FlagSet.
+		// panics if a flag is already registered.
+		p.Flags.VisitAll(func(flag *pflag.Flag) {
+			// enforce namespacing of flags by prefixing them with the format name.
+			if !strings.HasPrefix(flag.Name, p.Name) {
+				panic(fmt.Sprintf("flag %s must be prefixed with format name %s", flag.Name, p.Name))
+			}
+
+			if flags.Lookup(flag.Name) != nil {
+				panic(fmt.Sprintf("flag %s already registered", flag.Name))
+			}
+
+			flags.AddFlag(flag)
+		})
+	}
+
+	registeredFormats[p.Name] = p.New
+}
+
+func GetFormatter() (format.Formatter, error) {
+	newFunc, exists := registeredFormats[chosenFormat]
+	if !exists {
+		return nil, fmt.Errorf("format not registered: %s", chosenFormat)
+	}
+
+	formatter, err := newFunc(flags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s format: %w", chosenFormat, err)
+	}
+
+	return formatter, nil
+}
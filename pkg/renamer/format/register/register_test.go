@@ -0,0 +1,101 @@
+package register
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/TheoBrigitte/evansky/pkg/renamer/format"
+)
+
+func TestGetFormatter(t *testing.T) {
+	t.Cleanup(func() { chosenFormat = "" })
+
+	testCases := []struct {
+		name     string
+		expected format.Formatter
+	}{
+		{name: "jellyfin", expected: format.JellyfinFormatter{}},
+		{name: "plex", expected: format.PlexFormatter{}},
+		{name: "kodi", expected: format.KodiFormatter{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chosenFormat = tc.name
+			f, err := GetFormatter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f != tc.expected {
+				t.Errorf("expected formatter %T, got %T", tc.expected, f)
+			}
+		})
+	}
+
+	chosenFormat = "template"
+	f, err := GetFormatter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.(*format.TemplateFormatter); !ok {
+		t.Errorf("expected template formatter, got %T", f)
+	}
+
+	chosenFormat = "emby"
+	_, err = GetFormatter()
+	if err == nil {
+		t.Error("expected unknown format to be refused")
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	cmd := &cobra.Command{}
+	Initialize(cmd)
+
+	flag := cmd.PersistentFlags().Lookup("format")
+	if flag == nil {
+		t.Fatal("expected --format flag")
+	}
+	if flag.DefValue != defaultFormat {
+		t.Errorf("expected default format %q, got %q", defaultFormat, flag.DefValue)
+	}
+	if !strings.HasSuffix(flag.Usage, "jellyfin,kodi,plex,template") {
+		t.Errorf("expected usage to list the sorted formats, got %q", flag.Usage)
+	}
+	// Formats flags are available too.
+	for _, name := range []string{"jellyfin-provider-ids", "template-movie"} {
+		if cmd.PersistentFlags().Lookup(name) == nil {
+			t.Errorf("expected --%s flag", name)
+		}
+	}
+}
+
+func TestMustRegister(t *testing.T) {
+	unprefixed := pflag.NewFlagSet("emby", pflag.ExitOnError)
+	unprefixed.Bool("provider-ids", false, "")
+
+	testCases := []struct {
+		name   string
+		format format.FormatFunc
+	}{
+		{name: "empty name", format: func() format.Format { return format.Format{} }},
+		{name: "already registered", format: format.Plex},
+		{name: "unprefixed flag", format: func() format.Format { return format.Format{Name: "emby", Flags: unprefixed} }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registration to panic")
+				}
+			}()
+			mustRegister(tc.format)
+		})
+	}
+
+	if _, ok := registeredFormats["emby"]; ok {
+		t.Error("expected format failing to register to be left out")
+	}
+}
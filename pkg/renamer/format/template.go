@@ -0,0 +1,247 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/pflag"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/TheoBrigitte/evansky/pkg/parser"
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+// Default templates, they follow Jellyfin's naming conventions.
+const (
+	DefaultMovieTemplate     = "{{ .Name }} ({{ .Year }})/{{ .Name }} ({{ .Year }}){{ .Info.Edition | prefix \" - \" }}{{ if .Info.Part }} - part{{ .Info.Part }}{{ end }}"
+	DefaultTVShowTemplate    = "{{ .Name }} ({{ .Year }})"
+	DefaultTVSeasonTemplate  = "{{ .Name }} ({{ .Year }})/Season {{ .Season }}"
+	DefaultTVEpisodeTemplate = "{{ .Name }} ({{ .Year }})/Season {{ .Season }}/{{ .Name }} - S{{ pad 2 .Season }}E{{ pad 2 .Episode }}{{ if .EpisodeEnd }}-E{{ pad 2 .EpisodeEnd }}{{ end }} - {{ .EpisodeName }}"
+)
+
+// Flag variables
+var (
+	templateMovie     string
+	templateTVShow    string
+	templateTVSeason  string
+	templateTVEpisode string
+)
+
+// pathReplacer replaces path separators found in names, which would otherwise create unwanted directories.
+var pathReplacer = strings.NewReplacer("/", "-", "\\", "-")
+
+// TemplateOptions holds the templates of a TemplateFormatter, one per media type.
+// Templates are Go text/template strings, the output is split on "/" into path components.
+// Empty templates default to their Jellyfin equivalent.
+type TemplateOptions struct {
+	Movie     string
+	TVShow    string
+	TVSeason  string
+	TVEpisode string
+}
+
+// TemplateData is the data given to templates.
+type TemplateData struct {
+	// Name is the name of the movie or the TV show
+	Name string
+	// Year is the release year of the movie or the first air year of the TV show
+	Year int
+	// Provider is the name of the provider which produced the match
+	Provider string
+	// ID is the identifier of the movie or the TV show within the provider
+	ID int
+
+	// Season is the season number, for seasons and episodes
+	Season int
+	// Episode is the episode number, for episodes
+	Episode int
+	// EpisodeEnd is the number of the last episode of multi-episode files, 0 otherwise
+	EpisodeEnd int
+	// EpisodeName is the name of the episode, for episodes, names of multi-episode files are joined with " & "
+	EpisodeName string
+
+	// Info holds the information parsed from the source name (resolution, codec, group, etc.)
+	Info parser.Info
+}
+
+// TemplateFormatter formats destination paths using user defined templates.
+type TemplateFormatter struct {
+	movie     *template.Template
+	tvShow    *template.Template
+	tvSeason  *template.Template
+	tvEpisode *template.Template
+}
+
+// Template returns the template formatter with its flags.
+func Template() Format {
+	flags := pflag.NewFlagSet("template", pflag.ExitOnError)
+	flags.StringVar(&templateMovie, "template-movie", DefaultMovieTemplate, "template of movie destinations")
+	flags.StringVar(&templateTVShow, "template-tv", DefaultTVShowTemplate, "template of tv show destinations")
+	flags.StringVar(&templateTVSeason, "template-season", DefaultTVSeasonTemplate, "template of tv season destinations")
+	flags.StringVar(&templateTVEpisode, "template-episode", DefaultTVEpisodeTemplate, "template of tv episode destinations")
+
+	return Format{
+		Name: "template",
+		New: func(*pflag.FlagSet) (Formatter, error) {
+			return NewTemplateFormatter(TemplateOptions{
+				Movie:     templateMovie,
+				TVShow:    templateTVShow,
+				TVSeason:  templateTVSeason,
+				TVEpisode: templateTVEpisode,
+			})
+		},
+		Flags: flags,
+	}
+}
+
+// NewTemplateFormatter parses the given templates and returns a TemplateFormatter.
+func NewTemplateFormatter(o TemplateOptions) (*TemplateFormatter, error) {
+	f := &TemplateFormatter{}
+
+	templates := []struct {
+		name  string
+		text  string
+		def   string
+		field **template.Template
+	}{
+		{"movie", o.Movie, DefaultMovieTemplate, &f.movie},
+		{"tv", o.TVShow, DefaultTVShowTemplate, &f.tvShow},
+		{"season", o.TVSeason, DefaultTVSeasonTemplate, &f.tvSeason},
+		{"episode", o.TVEpisode, DefaultTVEpisodeTemplate, &f.tvEpisode},
+	}
+
+	for _, t := range templates {
+		text := t.text
+		if text == "" {
+			text = t.def
+		}
+
+		tmpl, err := template.New(t.name).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", t.name, err)
+		}
+		*t.field = tmpl
+	}
+
+	return f, nil
+}
+
+// Movie formats a movie using the movie template.
+func (f *TemplateFormatter) Movie(m provider.ResponseMovie, n source.Node) []string {
+	data := newTemplateData(m, n)
+
+	return execute(f.movie, data)
+}
+
+// TVShow formats a TV show using the tv template.
+func (f *TemplateFormatter) TVShow(tv provider.ResponseTV, n source.Node) []string {
+	data := newTemplateData(tv, n)
+
+	return execute(f.tvShow, data)
+}
+
+// TVSeason formats a TV season using the season template.
+func (f *TemplateFormatter) TVSeason(s provider.ResponseTVSeason, n source.Node) []string {
+	data := newTemplateData(s.GetShow(), n)
+	data.Season = s.GetSeasonNumber()
+
+	return execute(f.tvSeason, data)
+}
+
+// TVEpisode formats a TV episode using the episode template.
+func (f *TemplateFormatter) TVEpisode(e provider.ResponseTVEpisode, n source.Node) []string {
+	season := e.GetSeason()
+
+	data := newTemplateData(season.GetShow(), n)
+	data.Season = season.GetSeasonNumber()
+	data.Episode = e.GetEpisodeNumber()
+
+	episodes := episodesOf(e, n)
+	if len(episodes) > 1 {
+		data.EpisodeEnd = episodes[len(episodes)-1].GetEpisodeNumber()
+	}
+	data.EpisodeName = pathReplacer.Replace(episodeNames(episodes))
+
+	return execute(f.tvEpisode, data)
+}
+
+// FileSuffix appends the subtitle language to subtitle files.
+func (f *TemplateFormatter) FileSuffix(name string, n source.Node) string {
+	return languageSuffix(name, n)
+}
+
+// newTemplateData returns the template data of the movie or TV show r.
+func newTemplateData(r provider.Response, n source.Node) TemplateData {
+	return TemplateData{
+		Name:     pathReplacer.Replace(r.GetName()),
+		Year:     r.GetDate().Year(),
+		Provider: r.GetProvider(),
+		ID:       r.GetID(),
+		Info:     n.Info,
+	}
+}
+
+// execute executes the template and splits its output into path components.
+// Empty components are dropped, an error yields no component at all.
+func execute(t *template.Template, data TemplateData) []string {
+	var b strings.Builder
+	err := t.Execute(&b, data)
+	if err != nil {
+		log.Err(err).Str("template", t.Name()).Msg("failed to execute template")
+		return nil
+	}
+
+	var components []string
+	for c := range strings.SplitSeq(b.String(), "/") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		components = append(components, c)
+	}
+
+	return components
+}
+
+// templateFuncs are the helper functions available in templates.
+var templateFuncs = template.FuncMap{
+	// pad left pads the number n with zeros up to width digits, e.g. {{ pad 2 .Season }}
+	"pad": func(width, n int) string {
+		return fmt.Sprintf("%0*d", width, n)
+	},
+	// transliterate removes diacritics, e.g. {{ transliterate .Name }}
+	"transliterate": transliterate,
+	// prefix prepends p to s when s is not empty, e.g. {{ .Info.Resolution | prefix " - " }}
+	"prefix": func(p, s string) string {
+		if s == "" {
+			return ""
+		}
+		return p + s
+	},
+	// suffix appends p to s when s is not empty, e.g. {{ .Info.Group | suffix " " }}
+	"suffix": func(p, s string) string {
+		if s == "" {
+			return ""
+		}
+		return s + p
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// transliterate removes diacritics from s.
+func transliterate(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+
+	return result
+}
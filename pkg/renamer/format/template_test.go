@@ -0,0 +1,131 @@
+package format
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/TheoBrigitte/evansky/pkg/parser"
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	data := TemplateData{Name: "Amélie", Season: 1, Info: parser.Info{Resolution: "1080p"}}
+	testCases := []testCase{
+		{input: "{{ pad 2 .Season }}", expected: "01"},
+		{input: "{{ pad 3 .Season }}", expected: "001"},
+		{input: "{{ pad 1 12 }}", expected: "12"},
+		{input: "{{ transliterate .Name }}", expected: "Amelie"},
+		{input: "{{ transliterate \"Crème Brûlée\" }}", expected: "Creme Brulee"},
+		{input: "{{ .Info.Resolution | prefix \" - \" }}", expected: " - 1080p"},
+		{input: "{{ .Info.Codec | prefix \" - \" }}", expected: ""},
+		{input: "{{ .Info.Resolution | suffix \" \" }}", expected: "1080p "},
+		{input: "{{ .Info.Group | suffix \" \" }}", expected: ""},
+		{input: "{{ lower .Name }}", expected: "amélie"},
+		{input: "{{ upper .Name }}", expected: "AMÉLIE"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("templateFuncs_%03d", i), func(t *testing.T) {
+			tmpl, err := template.New("test").Funcs(templateFuncs).Parse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			err = tmpl.Execute(&b, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := b.String(); result != tc.expected {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", tc.input, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "{{ .Name }}", expected: []string{"Dark"}},
+		{input: "Shows/{{ .Name }}/Season {{ .Season }}", expected: []string{"Shows", "Dark", "Season 1"}},
+		// Components are trimmed, empty ones are dropped.
+		{input: " Shows / {{ .Name }} /", expected: []string{"Shows", "Dark"}},
+		{input: "Shows//{{ .Info.Group }}/{{ .Name }}", expected: []string{"Shows", "Dark"}},
+		{input: " / ", expected: nil},
+		// Failing templates yield no component.
+		{input: "{{ .Name.Missing }}", expected: nil},
+	}
+
+	data := TemplateData{Name: "Dark", Season: 1}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("execute_%03d", i), func(t *testing.T) {
+			tmpl := template.Must(template.New("test").Funcs(templateFuncs).Parse(tc.input))
+			result := execute(tmpl, data)
+			if !slices.Equal(result, tc.expected) {
+				t.Errorf("For input '%s', expected '%q' but got '%q'", tc.input, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTemplateFormatter(t *testing.T) {
+	defaults, err := NewTemplateFormatter(TemplateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := NewTemplateFormatter(TemplateOptions{
+		Movie:     "Movies/{{ .Name }} [{{ .Provider }}-{{ .ID }}]/{{ transliterate .Name }}{{ .Info.Resolution | prefix \" - \" }}",
+		TVEpisode: "Shows/{{ .Name }}/S{{ pad 2 .Season }}E{{ pad 2 .Episode }}{{ if .EpisodeEnd }}-E{{ pad 2 .EpisodeEnd }}{{ end }} {{ .EpisodeName }}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	episode, episodeFile := episodeNode(t, 1, 2)
+	special, specialFile := episodeNode(t, 0, 1)
+	multi, multiFile := episodeNode(t, 1, 3, 4)
+	// Path separators of names do not create directories.
+	slashed := providertest.NewMovieResponse(providertest.Movie{ID: 1, Name: "AC/DC: Let There Be Rock", Year: 1980}, provider.Request{})
+
+	testCases := []struct {
+		name     string
+		result   []string
+		expected string
+	}{
+		{name: "movie", result: defaults.Movie(testMovie, mediaNode(parser.Info{})), expected: "The Matrix (1999)/The Matrix (1999)"},
+		{name: "movie edition part", result: defaults.Movie(testMovie, mediaNode(parser.Info{Edition: "Extended", Part: 2})), expected: "The Matrix (1999)/The Matrix (1999) - Extended - part2"},
+		{name: "movie slashed", result: defaults.Movie(slashed, mediaNode(parser.Info{})), expected: "AC-DC: Let There Be Rock (1980)/AC-DC: Let There Be Rock (1980)"},
+		{name: "show", result: defaults.TVShow(testShow, mediaNode(parser.Info{})), expected: "Dark (2017)"},
+		{name: "season", result: defaults.TVSeason(episode.GetSeason(), mediaNode(parser.Info{})), expected: "Dark (2017)/Season 1"},
+		{name: "episode", result: defaults.TVEpisode(episode, episodeFile), expected: "Dark (2017)/Season 1/Dark - S01E02 - Episode 2"},
+		{name: "special", result: defaults.TVEpisode(special, specialFile), expected: "Dark (2017)/Season 0/Dark - S00E01 - Episode 1"},
+		{name: "multi-episode", result: defaults.TVEpisode(multi, multiFile), expected: "Dark (2017)/Season 1/Dark - S01E03-E04 - Episode 3 & Episode 4"},
+		{name: "custom movie", result: custom.Movie(testMovie, mediaNode(parser.Info{Resolution: "1080p"})), expected: "Movies/The Matrix [test-603]/The Matrix - 1080p"},
+		{name: "custom episode", result: custom.TVEpisode(episode, episodeFile), expected: "Shows/Dark/S01E02 Episode 2"},
+		{name: "custom special", result: custom.TVEpisode(special, specialFile), expected: "Shows/Dark/S00E01 Episode 1"},
+		{name: "custom multi-episode", result: custom.TVEpisode(multi, multiFile), expected: "Shows/Dark/S01E03-E04 Episode 3 & Episode 4"},
+		// Templates left empty keep their default.
+		{name: "custom show", result: custom.TVShow(testShow, mediaNode(parser.Info{})), expected: "Dark (2017)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := strings.Join(tc.result, "/")
+			if result != tc.expected {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", tc.name, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestNewTemplateFormatterInvalid(t *testing.T) {
+	_, err := NewTemplateFormatter(TemplateOptions{TVSeason: "{{ .Name"})
+	if err == nil || !strings.Contains(err.Error(), "season template") {
+		t.Errorf("expected invalid season template to be refused, got %v", err)
+	}
+}
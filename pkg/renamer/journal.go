@@ -0,0 +1,238 @@
+package renamer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultJournalDir is the journal directory relative to the user cache directory.
+	defaultJournalDir = "evansky/journal"
+	// journalExt is the extension of journal files.
+	journalExt = ".jsonl"
+	// undoneJournalExt is the extension of journal files whose run has been undone.
+	undoneJournalExt = ".undone" + journalExt
+	// undoStateExt is the extension of files recording the progress of a run being undone.
+	undoStateExt = ".undo"
+	// backupExt is the extension of replaced destinations saved during a run.
+	backupExt = ".bak"
+	// runIDFormat is the time format used to generate run identifiers, it sorts chronologically.
+	runIDFormat = "20060102T150405Z"
+)
+
+// Journal operations.
+const (
+	opMkdir    = "mkdir"
+	opReplace  = "replace"
+	opSymlink  = "symlink"
+	opHardlink = "hardlink"
+	opCopy     = "copy"
+	opMove     = "move"
+)
+
+// JournalRecord is a single file system operation performed during a run.
+type JournalRecord struct {
+	// Op is the operation performed
+	Op string `json:"op"`
+	// Source is the original path of the file, empty for mkdir and replace operations
+	Source string `json:"source,omitempty"`
+	// Destination is the path which was created or replaced
+	Destination string `json:"destination"`
+	// Backup is the path where a replaced destination was saved, if any
+	Backup string `json:"backup,omitempty"`
+	// LinkTarget is the target of a replaced symlink destination, if any
+	LinkTarget string `json:"link_target,omitempty"`
+	// Files lists the entries written within a copied or hard linked directory, relative to the destination and parents first.
+	// Only those are removed on undo, files added afterwards are kept.
+	Files []string `json:"files,omitempty"`
+}
+
+// Journal records every file system operation of a run, in order to be able to undo it.
+// Records are appended as operations happen, so an interrupted run can be undone as well.
+type Journal struct {
+	dir     string
+	runID   string
+	file    *os.File
+	encoder *json.Encoder
+	// records is the number of records written
+	records int
+}
+
+// DefaultJournalDir returns the default journal directory.
+func DefaultJournalDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, defaultJournalDir), nil
+}
+
+// OpenJournal creates a new journal for a run inside dir.
+func OpenJournal(dir string) (*Journal, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal directory %q: %w", dir, err)
+	}
+
+	// Generate a unique run identifier, add a counter in case of collision.
+	base := time.Now().UTC().Format(runIDFormat)
+	runID := base
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(filepath.Join(dir, runID+journalExt), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec
+		if err == nil {
+			j := &Journal{
+				dir:     dir,
+				runID:   runID,
+				file:    f,
+				encoder: json.NewEncoder(f),
+			}
+			return j, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create journal: %w", err)
+		}
+
+		runID = base + "-" + strconv.Itoa(i)
+	}
+}
+
+// RunID returns the identifier of the run recorded by the journal.
+func (j *Journal) RunID() string {
+	return j.runID
+}
+
+// Close closes the journal file, which is removed when nothing was recorded, as there is nothing to undo.
+func (j *Journal) Close() error {
+	err := j.file.Close()
+	if err != nil || j.records > 0 {
+		return err
+	}
+
+	return os.Remove(filepath.Join(j.dir, j.runID+journalExt))
+}
+
+// Recorded returns whether some operations were recorded.
+func (j *Journal) Recorded() bool {
+	return j.records > 0
+}
+
+// record appends a record to the journal.
+// Paths are made absolute so the run can be undone from any working directory.
+func (j *Journal) record(rec JournalRecord) error {
+	var err error
+	for _, path := range []*string{&rec.Source, &rec.Destination} {
+		if *path == "" {
+			continue
+		}
+		*path, err = filepath.Abs(*path)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path of %q: %w", *path, err)
+		}
+	}
+
+	err = j.encoder.Encode(rec)
+	if err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	j.records++
+
+	return nil
+}
+
+// backup saves the existing destination before it gets replaced, and records it.
+// Symlinks are recorded by their target, other files are renamed next to the destination,
+// which keeps them on the same filesystem. The destination is left in place when it cannot be saved.
+func (j *Journal) backup(dst string) error {
+	info, err := os.Lstat(dst)
+	if err != nil {
+		return fmt.Errorf("failed to stat existing destination %q: %w", dst, err)
+	}
+
+	rec := JournalRecord{
+		Op:          opReplace,
+		Destination: dst,
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		rec.LinkTarget, err = os.Readlink(dst)
+		if err != nil {
+			return fmt.Errorf("failed to read existing destination link %q: %w", dst, err)
+		}
+
+		err = os.Remove(dst)
+		if err != nil {
+			return fmt.Errorf("failed to remove existing destination %q: %w", dst, err)
+		}
+	} else {
+		rec.Backup, err = setAside(dst, j.runID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return j.record(rec)
+}
+
+// setAside renames the existing destination dst next to it, which keeps it on the same filesystem, and returns its new path.
+// Unlike removing it, this works for non-empty directories too, and the destination can be restored.
+func setAside(dst, runID string) (string, error) {
+	backup := backupPath(dst, runID)
+	_, err := os.Lstat(backup)
+	if err == nil {
+		return "", fmt.Errorf("failed to save existing destination %q: backup %q already exists", dst, backup)
+	}
+
+	err = os.Rename(dst, backup)
+	if err != nil {
+		return "", fmt.Errorf("failed to save existing destination %q: %w", dst, err)
+	}
+
+	return backup, nil
+}
+
+// backupPath returns the path where dst is saved when replaced during the run runID.
+func backupPath(dst, runID string) string {
+	return dst + ".evansky-" + runID + backupExt
+}
+
+// mkdirAll creates dir along with any necessary parents, and records every directory it created.
+func (j *Journal) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; {
+		_, err := os.Lstat(d)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		missing = append(missing, d)
+
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+	}
+
+	err := os.MkdirAll(dir, 0o755) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	// Record parents first, so they are removed last on undo.
+	for i := len(missing) - 1; i >= 0; i-- {
+		err := j.record(JournalRecord{Op: opMkdir, Destination: missing[i]})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+package renamer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// recordRun performs and records a run moving a.mkv into a new directory, and b.mkv over an existing file.
+func recordRun(t *testing.T, journalDir, src, dst string) string {
+	t.Helper()
+
+	writeFile(t, filepath.Join(src, "a.mkv"), "a")
+	writeFile(t, filepath.Join(src, "b.mkv"), "b")
+	writeFile(t, filepath.Join(dst, "B.mkv"), "old")
+
+	j, err := OpenJournal(journalDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close() //nolint:errcheck
+
+	moves := []struct{ src, dst string }{
+		{filepath.Join(src, "a.mkv"), filepath.Join(dst, "A", "A.mkv")},
+		{filepath.Join(src, "b.mkv"), filepath.Join(dst, "B.mkv")},
+	}
+	for _, m := range moves {
+		err = j.mkdirAll(filepath.Dir(m.dst))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Lstat(m.dst); err == nil {
+			err = j.backup(m.dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = moveFile(t.Context(), m.src, m.dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = j.record(JournalRecord{Op: opMove, Source: m.src, Destination: m.dst})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return j.RunID()
+}
+
+func TestUndo(t *testing.T) {
+	journalDir, src, dst := t.TempDir(), t.TempDir(), t.TempDir()
+	runID := recordRun(t, journalDir, src, dst)
+
+	records, err := ReadJournal(journalDir, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := make([]string, 0, len(records))
+	for _, rec := range records {
+		ops = append(ops, rec.Op)
+	}
+	if expected := []string{opMkdir, opMove, opReplace, opMove}; !slices.Equal(ops, expected) {
+		t.Fatalf("expected operations %v, got %v", expected, ops)
+	}
+	if readFile(t, records[2].Backup) != "old" {
+		t.Errorf("expected replaced destination to be saved in %q", records[2].Backup)
+	}
+
+	// Dry run changes nothing.
+	err = Undo(t.Context(), journalDir, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, filepath.Join(dst, "B.mkv")) != "b" {
+		t.Fatal("dry run undo changed the destination")
+	}
+
+	err = Undo(t.Context(), journalDir, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertUndone(t, journalDir, src, dst, records[2].Backup)
+}
+
+func TestUndoResume(t *testing.T) {
+	journalDir, src, dst := t.TempDir(), t.TempDir(), t.TempDir()
+	runID := recordRun(t, journalDir, src, dst)
+
+	// A new file at the source of a.mkv makes its undo fail, b.mkv is undone.
+	writeFile(t, filepath.Join(src, "a.mkv"), "new")
+
+	err := Undo(t.Context(), journalDir, runID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs, err := Runs(journalDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(runs, []string{runID}) {
+		t.Fatalf("expected run %q to remain, got %v", runID, runs)
+	}
+	if readFile(t, filepath.Join(src, "b.mkv")) != "b" || readFile(t, filepath.Join(dst, "B.mkv")) != "old" {
+		t.Fatal("expected b.mkv to be undone")
+	}
+
+	// Once the conflict is gone, the remaining records are undone and already undone ones are skipped.
+	err = os.Remove(filepath.Join(src, "a.mkv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Undo(t.Context(), journalDir, runID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := ReadJournal(journalDir, runID+".undone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertUndone(t, journalDir, src, dst, records[2].Backup)
+}
+
+func TestUndoTree(t *testing.T) {
+	for _, op := range []string{opCopy, opHardlink} {
+		t.Run(op, func(t *testing.T) {
+			journalDir, root := t.TempDir(), t.TempDir()
+			src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+			writeTree(t, src)
+
+			write := hardlink
+			if op == opCopy {
+				write = func(src, dst string) error { return copyPath(t.Context(), src, dst) }
+			}
+			err := write(src, dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			j, err := OpenJournal(journalDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files, err := treeFiles(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = j.record(JournalRecord{Op: op, Source: src, Destination: dst, Files: files})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = j.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Files added after the run are kept, along with their directories.
+			writeFile(t, filepath.Join(dst, "Extras", "notes.txt"), "notes")
+			err = Undo(t.Context(), journalDir, j.RunID(), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"a.mkv", "link.mkv", filepath.Join("Extras", "b.mkv")} {
+				if _, err := os.Lstat(filepath.Join(dst, name)); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("expected %q to be removed, got %v", name, err)
+				}
+			}
+			if readFile(t, filepath.Join(dst, "Extras", "notes.txt")) != "notes" {
+				t.Error("expected file added after the run to be kept")
+			}
+			assertTree(t, src)
+			runs, err := Runs(journalDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(runs, []string{j.RunID()}) {
+				t.Fatalf("expected run %q to remain, got %v", j.RunID(), runs)
+			}
+
+			// Once the added file is gone, the directories are removed.
+			err = os.Remove(filepath.Join(dst, "Extras", "notes.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Undo(t.Context(), journalDir, j.RunID(), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Lstat(dst); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("expected destination to be removed, got %v", err)
+			}
+			assertTree(t, src)
+		})
+	}
+}
+
+func TestBackupKeepsDestination(t *testing.T) {
+	journalDir, dst := t.TempDir(), t.TempDir()
+
+	j, err := OpenJournal(journalDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close() //nolint:errcheck
+
+	file := filepath.Join(dst, "A.mkv")
+	writeFile(t, file, "old")
+	writeFile(t, backupPath(file, j.RunID()), "other")
+
+	err = j.backup(file)
+	if err == nil {
+		t.Fatal("expected backup to fail")
+	}
+	if readFile(t, file) != "old" {
+		t.Error("destination was removed without a backup")
+	}
+}
+
+func TestJournalNothingRecorded(t *testing.T) {
+	journalDir := t.TempDir()
+
+	j, err := OpenJournal(journalDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = j.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Recorded() {
+		t.Error("expected nothing to be recorded")
+	}
+
+	runs, err := Runs(journalDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) > 0 {
+		t.Errorf("expected empty run to be removed, got %v", runs)
+	}
+}
+
+func assertUndone(t *testing.T, journalDir, src, dst, backup string) {
+	t.Helper()
+
+	if readFile(t, filepath.Join(src, "a.mkv")) != "a" || readFile(t, filepath.Join(src, "b.mkv")) != "b" {
+		t.Error("expected sources to be moved back")
+	}
+	if readFile(t, filepath.Join(dst, "B.mkv")) != "old" {
+		t.Error("expected replaced destination to be restored")
+	}
+	for _, path := range []string{filepath.Join(dst, "A"), backup} {
+		if _, err := os.Lstat(path); err == nil {
+			t.Errorf("expected %q to be removed", path)
+		}
+	}
+
+	runs, err := Runs(journalDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) > 0 {
+		t.Errorf("expected no run left to undo, got %v", runs)
+	}
+	if states, _ := filepath.Glob(filepath.Join(journalDir, "*"+undoStateExt)); len(states) > 0 {
+		t.Errorf("expected undo state to be removed, got %v", states)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,188 @@
+package renamer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// rename renames files, it is replaced by tests to move files across filesystems.
+var rename = os.Rename
+
+// moveFile moves src to dst, src can be a file or a directory.
+// It uses rename(2) when both paths are on the same filesystem, and falls back
+// to a verified copy followed by the removal of src when they are not.
+// An interrupted copy is rolled back, src is then left untouched.
+func moveFile(ctx context.Context, src, dst string) error {
+	err := rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("failed to move %q to %q: %w", src, dst, err)
+	}
+
+	// Source and destination are on different filesystems.
+	sourceInfo, err := os.Lstat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat source %q: %w", src, err)
+	}
+
+	if sourceInfo.IsDir() {
+		// A partial copy is not left behind, source is still intact.
+		err = copyTree(src, dst, func(src, dst string) error { return copyVerified(ctx, src, dst) })
+		if err != nil {
+			return err
+		}
+
+		err = os.RemoveAll(src)
+		if err != nil {
+			return fmt.Errorf("failed to remove source directory %q after copy: %w", src, err)
+		}
+
+		return nil
+	}
+
+	err = copyVerified(ctx, src, dst)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(src)
+	if err != nil {
+		return fmt.Errorf("failed to remove source file %q after copy: %w", src, err)
+	}
+
+	return nil
+}
+
+// copyVerified copies the regular file src to dst and verifies the copy.
+// Permissions and modification time are preserved, media servers rely on the latter.
+func copyVerified(ctx context.Context, src, dst string) error {
+	sourceFileStat, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat source file %q: %w", src, err)
+	}
+
+	err = copyFile(ctx, src, dst)
+	if err != nil {
+		return err
+	}
+
+	err = verifyCopy(src, dst)
+	if err != nil {
+		// Do not leave a corrupted copy behind, source is still intact.
+		_ = os.Remove(dst)
+		return err
+	}
+
+	// A copy missing the mode or modification time is not left behind either.
+	err = os.Chmod(dst, sourceFileStat.Mode().Perm())
+	if err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to set mode of %q: %w", dst, err)
+	}
+	err = os.Chtimes(dst, sourceFileStat.ModTime(), sourceFileStat.ModTime())
+	if err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to set modification time of %q: %w", dst, err)
+	}
+
+	return nil
+}
+
+// verifyCopy ensures dst is an exact copy of src by comparing their sizes and checksums.
+func verifyCopy(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat source file %q: %w", src, err)
+	}
+	dstInfo, err := os.Stat(dst)
+	if err != nil {
+		return fmt.Errorf("failed to stat destination file %q: %w", dst, err)
+	}
+	if srcInfo.Size() != dstInfo.Size() {
+		return fmt.Errorf("copy verification failed: size mismatch between %q (%d) and %q (%d)", src, srcInfo.Size(), dst, dstInfo.Size())
+	}
+
+	srcSum, err := checksum(src)
+	if err != nil {
+		return err
+	}
+	dstSum, err := checksum(dst)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(srcSum, dstSum) {
+		return fmt.Errorf("copy verification failed: checksum mismatch between %q and %q", src, dst)
+	}
+
+	return nil
+}
+
+// checksum returns the sha256 sum of the file at path.
+func checksum(path string) ([]byte, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	return h.Sum(nil), nil
+}
+
+// checkSameDevice ensures the source and destination of every entry are located on the same filesystem.
+// Destination directories may not exist yet, in which case their closest existing parent is used.
+func checkSameDevice(entries []Entry) error {
+	for _, e := range entries {
+		if e.Error != nil {
+			continue
+		}
+
+		dir, err := existingParent(filepath.Dir(e.Destination))
+		if err != nil {
+			return err
+		}
+
+		same, err := sameDevice(e.Source, dir)
+		if err != nil {
+			return err
+		}
+		if !same {
+			return fmt.Errorf("cannot hardlink %q to %q: source and destination are on different filesystems", e.Source, e.Destination)
+		}
+	}
+
+	return nil
+}
+
+// existingParent returns the closest existing directory of path, path included.
+func existingParent(path string) (string, error) {
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", fmt.Errorf("no existing parent directory for %q", path)
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,107 @@
+package renamer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// crossDevice makes renames fail as between different filesystems, for the duration of the test.
+func crossDevice(t *testing.T) {
+	t.Helper()
+
+	rename = func(src, dst string) error {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { rename = os.Rename })
+}
+
+func TestMoveFileAcrossDevices(t *testing.T) {
+	crossDevice(t)
+	root := t.TempDir()
+
+	src := filepath.Join(root, "src", "a.mkv")
+	writeFile(t, src, "a")
+	modTime := time.Date(2001, time.April, 25, 0, 0, 0, 0, time.UTC)
+	err := os.Chmod(src, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chtimes(src, modTime, modTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(root, "dst.mkv")
+	err = moveFile(t.Context(), src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, dst) != "a" {
+		t.Error("expected file to be copied")
+	}
+	if _, err := os.Lstat(src); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected source to be removed, got %v", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 || !info.ModTime().Equal(modTime) {
+		t.Errorf("expected mode and modification time to be preserved, got %s %s", info.Mode(), info.ModTime())
+	}
+}
+
+func TestMoveInterrupted(t *testing.T) {
+	crossDevice(t)
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src.mkv"), filepath.Join(root, "dst.mkv")
+	writeFile(t, src, "a")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err := moveFile(ctx, src, dst)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected move to be interrupted, got %v", err)
+	}
+	if readFile(t, src) != "a" {
+		t.Error("expected source to be left untouched")
+	}
+	if _, err := os.Lstat(dst); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected partial copy to be removed, got %v", err)
+	}
+}
+
+func TestVerifyCopy(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src.mkv")
+	writeFile(t, src, "abc")
+
+	testCases := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{name: "identical", content: "abc", valid: true},
+		{name: "size mismatch", content: "ab", valid: false},
+		{name: "checksum mismatch", content: "abd", valid: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := filepath.Join(root, tc.name+".mkv")
+			writeFile(t, dst, tc.content)
+
+			err := verifyCopy(src, dst)
+			if tc.valid && err != nil {
+				t.Errorf("expected copy to be verified, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected copy verification to fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package renamer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+// planVersion is the version of the plan file format.
+const planVersion = 1
+
+var (
+	// ErrSourceChanged is returned when a source was modified between planning and applying.
+	ErrSourceChanged = errors.New("source changed since planning")
+)
+
+// Plan is the list of rename operations computed from a scan.
+// It can be saved to a file and applied later without querying any provider.
+type Plan struct {
+	// Version is the plan file format version
+	Version int `json:"version"`
+	// CreatedAt is the time at which the plan was generated
+	CreatedAt time.Time `json:"created_at"`
+	// RenameMode is the rename mode the plan was generated for
+	RenameMode string `json:"rename_mode"`
+	// Directories lists the directories to create before renaming
+	Directories []string `json:"directories"`
+	// Entries lists the rename operations
+	Entries []Entry `json:"entries"`
+}
+
+// NewPlan returns an empty plan for the given rename mode.
+func NewPlan(renameMode string) *Plan {
+	return &Plan{
+		Version:    planVersion,
+		CreatedAt:  time.Now(),
+		RenameMode: renameMode,
+	}
+}
+
+// entryJSON is the serialized form of an Entry.
+type entryJSON struct {
+	Source      string               `json:"source"`
+	Destination string               `json:"destination,omitempty"`
+	MediaType   provider.MediaType   `json:"media_type,omitempty"`
+	Provider    string               `json:"provider,omitempty"`
+	ProviderID  int                  `json:"provider_id,omitempty"`
+	ShowID      int                  `json:"show_id,omitempty"`
+	Candidates  []provider.Candidate `json:"candidates,omitempty"`
+	Size        int64                `json:"size"`
+	ModTime     time.Time            `json:"mtime"`
+	Error       string               `json:"error,omitempty"`
+	Excluded    bool                 `json:"excluded,omitempty"`
+	Ignored     bool                 `json:"ignored,omitempty"`
+	NeedsReview bool                 `json:"needs_review,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	j := entryJSON{
+		Source:      e.Source,
+		Destination: e.Destination,
+		MediaType:   e.MediaType,
+		Provider:    e.Provider,
+		ProviderID:  e.ProviderID,
+		ShowID:      e.ShowID,
+		Candidates:  e.Candidates,
+		Size:        e.Size,
+		ModTime:     e.ModTime,
+	}
+	if e.Error != nil {
+		j.Error = e.Error.Error()
+		j.Excluded = errors.Is(e.Error, source.ErrExcludedPath)
+		j.Ignored = errors.Is(e.Error, source.ErrIgnoredPath)
+		j.NeedsReview = errors.Is(e.Error, source.ErrNeedsReview)
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var j entryJSON
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+
+	*e = Entry{
+		Source:      j.Source,
+		Destination: j.Destination,
+		MediaType:   j.MediaType,
+		Provider:    j.Provider,
+		ProviderID:  j.ProviderID,
+		ShowID:      j.ShowID,
+		Candidates:  j.Candidates,
+		Size:        j.Size,
+		ModTime:     j.ModTime,
+	}
+	if j.Error != "" {
+		e.Error = &planError{msg: j.Error, excluded: j.Excluded, ignored: j.Ignored, needsReview: j.NeedsReview}
+	}
+
+	return nil
+}
+
+// planError is an entry error restored from a plan file.
+type planError struct {
+	msg         string
+	excluded    bool
+	ignored     bool
+	needsReview bool
+}
+
+func (e *planError) Error() string {
+	return e.msg
+}
+
+func (e *planError) Is(target error) bool {
+	return (e.excluded && target == source.ErrExcludedPath) ||
+		(e.ignored && target == source.ErrIgnoredPath) ||
+		(e.needsReview && target == source.ErrNeedsReview)
+}
+
+// WritePlan saves the plan into the file at path.
+// Paths are made absolute so the plan can be applied from any working directory.
+func WritePlan(path string, plan *Plan) error {
+	p := *plan
+	p.Directories = make([]string, 0, len(plan.Directories))
+	for _, dir := range plan.Directories {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path of %q: %w", dir, err)
+		}
+		p.Directories = append(p.Directories, absDir)
+	}
+
+	p.Entries = make([]Entry, 0, len(plan.Entries))
+	for _, e := range plan.Entries {
+		var err error
+		e.Source, err = filepath.Abs(e.Source)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path of %q: %w", e.Source, err)
+		}
+		if e.Destination != "" {
+			e.Destination, err = filepath.Abs(e.Destination)
+			if err != nil {
+				return fmt.Errorf("failed to get absolute path of %q: %w", e.Destination, err)
+			}
+		}
+		p.Entries = append(p.Entries, e)
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+
+	err = os.WriteFile(path, append(data, '\n'), 0o644) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to write plan %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// ReadPlan loads a plan from the file at path.
+func ReadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan %q: %w", path, err)
+	}
+
+	var plan Plan
+	err = json.Unmarshal(data, &plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode plan %q: %w", path, err)
+	}
+
+	if plan.Version != planVersion {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", plan.Version, planVersion)
+	}
+
+	return &plan, nil
+}
+
+// Apply executes a previously generated plan without querying any provider.
+// Entries whose source changed since planning are refused.
+func Apply(ctx context.Context, plan *Plan, o Options) error {
+	r := &renamer{
+		directories: make(map[string]struct{}),
+		files:       make(map[string]string),
+		errors:      make(map[string]error),
+		o:           o,
+	}
+	r.o.RenameMode = plan.RenameMode
+
+	for index := range plan.Entries {
+		if plan.Entries[index].Error != nil {
+			continue
+		}
+
+		plan.Entries[index].Error = checkSource(plan.Entries[index])
+	}
+
+	return r.Execute(ctx, plan)
+}
+
+// checkSource ensures the source of the entry still matches its recorded state.
+func checkSource(e Entry) error {
+	info, err := os.Lstat(e.Source)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSourceChanged, err)
+	}
+
+	if info.Size() != e.Size {
+		return fmt.Errorf("%w: size changed from %d to %d", ErrSourceChanged, e.Size, info.Size())
+	}
+
+	if !info.ModTime().Equal(e.ModTime) {
+		return fmt.Errorf("%w: modification time changed from %s to %s", ErrSourceChanged, e.ModTime, info.ModTime())
+	}
+
+	return nil
+}
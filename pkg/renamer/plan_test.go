@@ -0,0 +1,126 @@
+package renamer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+func TestPlanRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+
+	plan := NewPlan("move")
+	plan.Directories = []string{"out"}
+	plan.Entries = []Entry{
+		{
+			Source:      "a.mkv",
+			Destination: filepath.Join("out", "A (2001).mkv"),
+			MediaType:   provider.MediaTypeMovie,
+			Provider:    "test",
+			ProviderID:  1,
+			Candidates:  []provider.Candidate{{Provider: "test", ID: 1, MediaType: provider.MediaTypeMovie, Name: "A", Year: 2001, Score: 12.5}},
+			Size:        3,
+			ModTime:     time.Date(2001, time.April, 25, 0, 0, 0, 0, time.UTC),
+		},
+		{Source: "sample.mkv", Error: fmt.Errorf("%w by glob", source.ErrExcludedPath)},
+		{Source: "notes.txt", Error: fmt.Errorf("%w: unknown file type", source.ErrIgnoredPath)},
+		{Source: "b.mkv", Error: fmt.Errorf("%w: confidence 0.40", source.ErrNeedsReview)},
+		{Source: "c.mkv", Error: errors.New("no result")},
+	}
+
+	path := filepath.Join(root, "plan.json")
+	err := WritePlan(path, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ReadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Version != planVersion || result.RenameMode != "move" || !result.CreatedAt.Equal(plan.CreatedAt) {
+		t.Errorf("expected plan header to be kept, got %d %q %s", result.Version, result.RenameMode, result.CreatedAt)
+	}
+	// Paths are made absolute.
+	if expected := []string{filepath.Join(root, "out")}; !slices.Equal(result.Directories, expected) {
+		t.Errorf("expected directories %v, got %v", expected, result.Directories)
+	}
+	if len(result.Entries) != len(plan.Entries) {
+		t.Fatalf("expected %d entries, got %d", len(plan.Entries), len(result.Entries))
+	}
+
+	e := result.Entries[0]
+	if e.Source != filepath.Join(root, "a.mkv") || e.Destination != filepath.Join(root, "out", "A (2001).mkv") {
+		t.Errorf("expected absolute paths, got %q and %q", e.Source, e.Destination)
+	}
+	if e.MediaType != provider.MediaTypeMovie || e.Provider != "test" || e.ProviderID != 1 || e.Size != 3 || !e.ModTime.Equal(plan.Entries[0].ModTime) {
+		t.Errorf("expected entry to be kept, got %+v", e)
+	}
+	if !slices.Equal(e.Candidates, plan.Entries[0].Candidates) {
+		t.Errorf("expected candidates %v, got %v", plan.Entries[0].Candidates, e.Candidates)
+	}
+
+	// Errors keep their message and their kind.
+	for i, expected := range []error{source.ErrExcludedPath, source.ErrIgnoredPath, source.ErrNeedsReview, nil} {
+		e := result.Entries[i+1]
+		if e.Error == nil || e.Error.Error() != plan.Entries[i+1].Error.Error() {
+			t.Errorf("expected error %q, got %v", plan.Entries[i+1].Error, e.Error)
+		}
+		for _, kind := range []error{source.ErrExcludedPath, source.ErrIgnoredPath, source.ErrNeedsReview} {
+			if errors.Is(e.Error, kind) != (kind == expected) {
+				t.Errorf("expected error %q of entry %q to be %v: %t", e.Error, e.Source, kind, kind == expected)
+			}
+		}
+	}
+}
+
+func TestReadPlanVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	writeFile(t, path, `{"version": 99, "entries": []}`)
+
+	_, err := ReadPlan(path)
+	if err == nil {
+		t.Error("expected plan of an unknown version to be refused")
+	}
+}
+
+func TestCheckSource(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.mkv")
+	writeFile(t, path, "abc")
+	modTime := time.Date(2001, time.April, 25, 0, 0, 0, 0, time.UTC)
+	err := os.Chtimes(path, modTime, modTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name  string
+		entry Entry
+		valid bool
+	}{
+		{name: "unchanged", entry: Entry{Source: path, Size: 3, ModTime: modTime}, valid: true},
+		{name: "size changed", entry: Entry{Source: path, Size: 4, ModTime: modTime}, valid: false},
+		{name: "modification time changed", entry: Entry{Source: path, Size: 3, ModTime: modTime.Add(time.Second)}, valid: false},
+		{name: "missing", entry: Entry{Source: filepath.Join(root, "b.mkv"), Size: 3, ModTime: modTime}, valid: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkSource(tc.entry)
+			if tc.valid && err != nil {
+				t.Errorf("expected source to be unchanged, got %v", err)
+			}
+			if !tc.valid && !errors.Is(err, ErrSourceChanged) {
+				t.Errorf("expected source to be changed, got %v", err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package renamer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,6 +9,8 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
@@ -17,6 +20,11 @@ import (
 )
 
 var (
+	// ErrDestinationExists is set on entries skipped because their destination already exists.
+	ErrDestinationExists = errors.New("destination already exists")
+	// ErrDuplicatePath is set on entries sharing their source or destination with another entry.
+	ErrDuplicatePath = errors.New("duplicate path")
+
 	// deduplicationAttemptLimit defines the maximum number of attempts to deduplicate
 	// destination paths by appending suffixes
 	deduplicationAttemptLimit = 2
@@ -34,8 +42,13 @@ type renamer struct {
 	// errors tracks errors encountered during entry generation
 	errors map[string]error
 
+	// journal records file system operations of the current run, nil when disabled
+	journal *Journal
+
 	// o contains the configuration options for the renamer
 	o Options
+	// sourceOptions contains the options of the last scan
+	sourceOptions source.Options
 	// paths contains the source paths to scan for media files
 	paths []string
 	// providers contains the metadata providers to use for lookups
@@ -44,13 +57,21 @@ type renamer struct {
 
 // Options configures the behavior of the renamer.
 type Options struct {
+	// Directories enables renaming whole directories instead of the files they contain
+	Directories bool
 	// Force enables overwriting existing files at the destination
 	Force bool
 	// Formatter defines how to format the destination filenames
 	Formatter format.Formatter
+	// JournalDir is the directory where runs are recorded in order to be undone, empty disables the journal
+	JournalDir string
 	// Output specifies the base directory for renamed files
 	Output string
-	// RenameMode determines how files are renamed ("symlink" or "copy")
+	// OutputFormat is the format of the results: "text" (default), "json" or "jsonl"
+	OutputFormat string
+	// Report is where machine-readable results are written, defaults to os.Stdout
+	Report io.Writer
+	// RenameMode determines how files are renamed ("symlink", "hardlink", "copy" or "move")
 	RenameMode string
 	// SkipExisting skips renaming if the destination already exists
 	SkipExisting bool
@@ -66,6 +87,28 @@ type Entry struct {
 	Error error
 	// Source is the original path of the file
 	Source string
+
+	// MediaType is the type of media matched for the source
+	MediaType provider.MediaType
+	// Provider is the name of the provider which produced the match
+	Provider string
+	// ProviderID is the identifier of the match within the provider
+	ProviderID int
+	// ShowID is the identifier of the show of a season or an episode within the provider
+	ShowID int
+	// Candidates lists the possible matches considered for the source, best first.
+	// Seasons and episodes are not searched, their candidates are those of their show.
+	Candidates []provider.Candidate
+
+	// Size is the size of the source at planning time
+	Size int64
+	// ModTime is the modification time of the source at planning time
+	ModTime time.Time
+
+	// node is the scanned node of the entry, only available for plans which were not loaded from a file
+	node *source.Node
+	// companions is the number of companion entries following this entry
+	companions int
 }
 
 // New creates a new Renamer instance with the given paths, providers, and options.
@@ -92,74 +135,149 @@ func New(paths []string, providers []provider.Interface, o Options) (*renamer, e
 
 // Run executes the renaming process by scanning paths, generating entries,
 // creating directories, and performing rename operations based on the configured mode.
-func (r *renamer) Run(o source.Options) (err error) {
-	// log output prefix
-	prefix := ""
-	if !r.o.Write {
-		prefix = "[dry-run] "
+func (r *renamer) Run(ctx context.Context, o source.Options) error {
+	plan, err := r.Plan(ctx, o)
+	if err != nil {
+		return err
 	}
 
-	// Select the appropriate writer function based on the rename mode
-	var w writer
-	switch r.o.RenameMode {
-	case "symlink":
-		w = os.Symlink
-	case "copy":
-		w = copyFile
-	default:
-		return fmt.Errorf("unknown rename mode: %s", r.o.RenameMode)
-	}
+	return r.Execute(ctx, plan)
+}
 
+// Plan scans all paths and generates the rename entries, without touching the file system.
+// The resulting plan can be executed right away or saved and applied later.
+// Once ctx is done, remaining entries are not scanned and hold the context error.
+func (r *renamer) Plan(ctx context.Context, o source.Options) (*Plan, error) {
 	log.Debug().Int("paths", len(r.paths)).Int("providers", len(r.providers)).Msgf("scanning")
 
-	// Scan all paths and collect inforations for renaming
-	nodes := make(map[string][]source.Node)
+	plan := NewPlan(r.o.RenameMode)
+	r.sourceOptions = o
+
+	// Searches are cached for this run, sibling entries of the same show are resolved once.
+	providers := make([]provider.Interface, 0, len(r.providers))
+	for _, p := range r.providers {
+		providers = append(providers, provider.Memoize(p))
+	}
+
+	// Scan all paths and generate rename entries using formatter and collected nodes
 	for _, path := range r.paths {
 		output := r.o.Output
 		if output == "" {
 			output = filepath.Dir(filepath.Clean(path))
 		}
 
-		n := source.Scan(path, r.providers, o)
-
-		nodes[output] = append(nodes[output], n...)
-	}
-
-	if len(nodes) == 0 {
-		log.Warn().Msg("no results found")
-		return nil
-	}
+		o.Directories = r.o.Directories
+		nodes := source.Scan(ctx, path, providers, o)
+		if r.o.Directories {
+			nodes = selectDirectories(nodes)
+		}
+		nodes = stackParts(nodes)
 
-	// Generate rename entries using formatter and collected nodes
-	entries := []Entry{}
-	dirs := []string{}
-	for output, nodes := range nodes {
 		for _, n := range nodes {
 			entry, dir := r.generateEntry(n, output)
-			if r.o.SkipExisting {
-				_, err := os.Lstat(dir)
+			entry.node = &n
+			entry.companions = len(n.Companions)
+			if r.o.SkipExisting && entry.Error == nil {
+				existing := dir
+				if n.Entry.IsDir() {
+					// The directory itself is the destination.
+					existing = entry.Destination
+				}
+				_, err := os.Lstat(existing)
 				if err != nil {
 					if !errors.Is(err, os.ErrNotExist) {
-						return err
+						return nil, err
 					}
 				} else {
 					// directory exists, skip renaming
-					entry.Error = fmt.Errorf("%w: destination directory already exists %q", source.ErrExcludedPath, dir)
+					entry.Error = fmt.Errorf("%w: %w %q", source.ErrExcludedPath, ErrDestinationExists, dir)
 				}
 			}
-			entries = append(entries, entry)
-			if dir != "" {
-				dirs = append(dirs, dir)
+			plan.Entries = append(plan.Entries, entry)
+			if dir != "" && entry.Error == nil {
+				plan.Directories = append(plan.Directories, dir)
+			}
+
+			// Companions are renamed along with their media
+			for _, c := range n.Companions {
+				plan.Entries = append(plan.Entries, r.generateCompanionEntry(c, n, entry))
 			}
 		}
 	}
 
+	slices.Sort(plan.Directories)
+	plan.Directories = slices.Compact(plan.Directories)
+
+	return plan, nil
+}
+
+// Execute creates the directories and performs the rename operations of the given plan,
+// then prints a summary of the results.
+// Once ctx is done, no new operation is started: the current one is finished, or rolled back for copies,
+// and the remaining entries are reported as not processed along with the summary.
+func (r *renamer) Execute(ctx context.Context, plan *Plan) (err error) {
+	// log output prefix
+	prefix := ""
+	if !r.o.Write {
+		prefix = "[dry-run] "
+	}
+
+	// Select the appropriate writer function based on the rename mode
+	var w writer
+	switch r.o.RenameMode {
+	case "symlink":
+		w = os.Symlink
+	case "hardlink":
+		w = hardlink
+	case "copy":
+		w = func(src, dst string) error { return copyPath(ctx, src, dst) }
+	case "move":
+		w = func(src, dst string) error { return moveFile(ctx, src, dst) }
+	default:
+		return fmt.Errorf("unknown rename mode: %s", r.o.RenameMode)
+	}
+
+	if r.o.OutputFormat != "" && !slices.Contains(OutputFormats, r.o.OutputFormat) {
+		return fmt.Errorf("unknown output format: %s", r.o.OutputFormat)
+	}
+
+	entries := plan.Entries
+	if len(entries) == 0 {
+		log.Warn().Msg("no results found")
+		return r.report(plan, 0)
+	}
+
+	// Hard links cannot cross filesystems, refuse to start if any entry would need to.
+	if r.o.RenameMode == "hardlink" {
+		err := checkSameDevice(entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Record all operations in order to be able to undo them
+	if r.o.Write && r.o.JournalDir != "" {
+		r.journal, err = OpenJournal(r.o.JournalDir)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err = errors.Join(err, r.journal.Close())
+			if r.journal.Recorded() {
+				log.Info().Str("run", r.journal.RunID()).Msgf("recorded run, undo it with: evansky undo %s", r.journal.RunID())
+			}
+			r.journal = nil
+		}()
+	}
+
 	// Start renaming by creating necessary uniq directories
 	dirCount := 0
-	slices.Sort(dirs)
-	for _, dir := range slices.Compact(dirs) {
+	for _, dir := range plan.Directories {
+		if ctx.Err() != nil {
+			break
+		}
 		if r.o.Write {
-			err := os.MkdirAll(dir, 0o755) //nolint:gosec
+			err := r.mkdirAll(dir)
 			if err != nil {
 				return fmt.Errorf("failed to create directory %q: %w", dir, err)
 			}
@@ -180,9 +298,14 @@ func (r *renamer) Run(o source.Options) (err error) {
 			continue
 		}
 
+		if ctx.Err() != nil {
+			entries[index].Error = fmt.Errorf("not processed: %w", context.Cause(ctx))
+			continue
+		}
+
 		// Check for duplicate destination paths
 		if _, exists := uniqEntries[entries[index].Destination]; exists {
-			entries[index].Error = fmt.Errorf("duplicate destination path: %s", entries[index].Destination)
+			entries[index].Error = fmt.Errorf("%w: destination %s", ErrDuplicatePath, entries[index].Destination)
 			continue
 		}
 
@@ -197,7 +320,7 @@ func (r *renamer) Run(o source.Options) (err error) {
 		}
 
 		// Perform the write operation
-		err := r.write(realSrc, entries[index].Destination, w)
+		err := r.write(entries[index].Source, realSrc, entries[index].Destination, w)
 		if err != nil {
 			entries[index].Error = err
 			continue
@@ -211,13 +334,23 @@ func (r *renamer) Run(o source.Options) (err error) {
 
 	// Print summary of errors and renamed files
 	errorsCount := 0
+	interruptedCount := 0
+	reviewCount := 0
 	for _, e := range entries {
 		if e.Error == nil {
 			continue
 		}
 
-		if errors.Is(e.Error, source.ErrExcludedPath) {
+		if isInterrupted(e.Error) {
+			log.Warn().Str("source", e.Source).Str("reason", e.Error.Error()).Msgf("%snot processed", prefix)
+			interruptedCount++
+		} else if errors.Is(e.Error, source.ErrNeedsReview) {
+			log.Warn().Str("source", e.Source).Str("reason", e.Error.Error()).Strs("candidates", topCandidates(e.Candidates)).Msgf("%sneeds review", prefix)
+			reviewCount++
+		} else if errors.Is(e.Error, source.ErrExcludedPath) {
 			log.Info().Str("source", e.Source).Str("reason", e.Error.Error()).Msgf("%sexcluded", prefix)
+		} else if errors.Is(e.Error, source.ErrIgnoredPath) {
+			log.Info().Str("source", e.Source).Str("reason", e.Error.Error()).Msgf("%signored", prefix)
 		} else {
 			log.Err(e.Error).Str("source", e.Source).Msgf("%srenaming failed", prefix)
 			errorsCount++
@@ -225,17 +358,67 @@ func (r *renamer) Run(o source.Options) (err error) {
 	}
 
 	e := log.Info()
-	if errorsCount > 0 {
+	if errorsCount > 0 || interruptedCount > 0 || reviewCount > 0 {
 		e = log.Warn()
 	}
 	e.Msgf("%srenamed %d/%d file(s)", prefix, renamedCount, len(entries))
+	if reviewCount > 0 {
+		log.Warn().Msgf("%s%d file(s) need review, see --min-confidence", prefix, reviewCount)
+	}
+	if interruptedCount > 0 {
+		log.Warn().Msgf("%sinterrupted, %d file(s) not processed", prefix, interruptedCount)
+	}
+
+	err = r.report(plan, dirCount)
+	if err != nil {
+		return err
+	}
 
-	// TODO: handle non destructive renaming, keeping other files (subtitles, etc)
-	// TODO: include directories in the renaming process
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted: %w", context.Cause(ctx))
+	}
 
 	return nil
 }
 
+// reviewCandidates is the number of candidates listed for entries which need review.
+const reviewCandidates = 3
+
+// topCandidates describes the best candidates, for entries which need review.
+func topCandidates(candidates []provider.Candidate) []string {
+	top := make([]string, 0, min(len(candidates), reviewCandidates))
+	for _, c := range candidates[:min(len(candidates), reviewCandidates)] {
+		confidence := 0.0
+		if c.Breakdown != nil {
+			confidence = c.Breakdown.Confidence
+		}
+		top = append(top, fmt.Sprintf("%s (%d) %s:%d confidence=%.2f", c.Name, c.Year, c.Provider, c.ID, confidence))
+	}
+
+	return top
+}
+
+// isInterrupted returns whether err is due to the run being interrupted.
+func isInterrupted(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// candidatesOf returns the candidates of the search which found resp.
+// Seasons and episodes are found within their show, their candidates are those of the show, whose ID is returned as well.
+func candidatesOf(resp provider.Response) ([]provider.Candidate, int) {
+	var show provider.ResponseTV
+	switch r := resp.(type) {
+	case provider.ResponseTVSeason:
+		show = r.GetShow()
+	case provider.ResponseTVEpisode:
+		show = r.GetSeason().GetShow()
+	default:
+		return resp.GetCandidates(), 0
+	}
+
+	return show.GetCandidates(), show.GetID()
+}
+
 // generateEntry creates an Entry from a source node by formatting the destination path
 // based on the node's metadata. It returns the entry and any parent directory to create.
 func (r *renamer) generateEntry(node source.Node, output string) (e Entry, dir string) {
@@ -243,6 +426,13 @@ func (r *renamer) generateEntry(node source.Node, output string) (e Entry, dir s
 
 	if node.Error != nil {
 		e.Error = node.Error
+		if errors.Is(node.Error, source.ErrNeedsReview) && node.Response != nil {
+			// Weak matches are not renamed, their candidates are kept for review.
+			e.MediaType = provider.MediaTypeOf(node.Response)
+			e.Provider = node.Response.GetProvider()
+			e.ProviderID = node.Response.GetID()
+			e.Candidates, e.ShowID = candidatesOf(node.Response)
+		}
 		return
 	}
 
@@ -261,11 +451,30 @@ func (r *renamer) generateEntry(node source.Node, output string) (e Entry, dir s
 		e.Error = fmt.Errorf("unknown type: %T", node.Response)
 		return
 	}
+	if node.Entry.IsDir() {
+		// Directories are renamed as a whole, movie file name does not apply.
+		if _, ok := node.Response.(provider.ResponseMovie); ok && len(components) > 1 {
+			components = components[:len(components)-1]
+		}
+	}
+	e.MediaType = provider.MediaTypeOf(node.Response)
+	e.Provider = node.Response.GetProvider()
+	e.ProviderID = node.Response.GetID()
+	e.Candidates, e.ShowID = candidatesOf(node.Response)
 	if len(components) == 0 {
 		e.Error = fmt.Errorf("no components")
 		return
 	}
 
+	// Record source state, used to detect changes between planning and applying
+	info, err := node.Entry.Info()
+	if err != nil {
+		e.Error = fmt.Errorf("failed to read source info: %w", err)
+		return
+	}
+	e.Size = info.Size()
+	e.ModTime = info.ModTime()
+
 	// Read file extension if not a directory
 	var extension string
 	if !node.Entry.IsDir() {
@@ -289,7 +498,7 @@ func (r *renamer) generateEntry(node source.Node, output string) (e Entry, dir s
 	// Check for duplicate source paths
 	// TODO: move this outside of this function, to avoid using r.files state
 	if _, exists := r.files[node.Path]; exists {
-		e.Error = fmt.Errorf("duplicate source path")
+		e.Error = fmt.Errorf("%w: source", ErrDuplicatePath)
 		return
 	}
 
@@ -300,6 +509,7 @@ func (r *renamer) generateEntry(node source.Node, output string) (e Entry, dir s
 		exists := slices.Contains(slices.Collect(maps.Values(r.files)), newPathWithExt)
 		if !exists {
 			e.Destination = newPathWithExt
+			r.files[node.Path] = newPathWithExt
 			return
 		}
 
@@ -307,29 +517,158 @@ func (r *renamer) generateEntry(node source.Node, output string) (e Entry, dir s
 		newPathWithExt = filepath.Clean(fmt.Sprintf("%s%s", newPath, extension))
 	}
 
-	e.Error = fmt.Errorf("could not deduplicate path")
+	e.Error = fmt.Errorf("%w: could not deduplicate destination", ErrDuplicatePath)
 	return
 }
 
-// writer is a function type that performs the actual file operation (symlink or copy).
+// generateCompanionEntry creates an Entry for the companion c of the media node, next to the media entry destination.
+// Companions of media which is not renamed are not renamed either, and like media they are skipped when their destination exists with SkipExisting.
+func (r *renamer) generateCompanionEntry(c, media source.Node, mediaEntry Entry) (e Entry) {
+	e.Source = c.Path
+	e.MediaType = mediaEntry.MediaType
+	e.Provider = mediaEntry.Provider
+	e.ProviderID = mediaEntry.ProviderID
+	e.ShowID = mediaEntry.ShowID
+
+	if mediaEntry.Error != nil {
+		e.Error = fmt.Errorf("%w: media %q is not renamed", source.ErrIgnoredPath, media.Path)
+		return
+	}
+
+	info, err := c.Entry.Info()
+	if err != nil {
+		e.Error = fmt.Errorf("failed to read source info: %w", err)
+		return
+	}
+	e.Size = info.Size()
+	e.ModTime = info.ModTime()
+
+	mediaName := strings.TrimSuffix(filepath.Base(mediaEntry.Destination), filepath.Ext(mediaEntry.Destination))
+	destination := filepath.Join(filepath.Dir(mediaEntry.Destination), source.CompanionName(c, media, mediaName))
+	if slices.Contains(slices.Collect(maps.Values(r.files)), destination) {
+		e.Error = fmt.Errorf("%w: destination %s", ErrDuplicatePath, destination)
+		return
+	}
+
+	e.Destination = destination
+	if r.o.SkipExisting {
+		_, err := os.Lstat(destination)
+		if err == nil {
+			// file exists, skip renaming
+			e.Error = fmt.Errorf("%w: %w %q", source.ErrExcludedPath, ErrDestinationExists, destination)
+			return
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			e.Error = err
+			return
+		}
+	}
+	r.files[c.Path] = destination
+
+	return
+}
+
+// selectDirectories returns the nodes to rename when renaming whole directories.
+// The outermost directories matching a movie, a TV show or a TV season are kept,
+// along with files not contained in any of them. Content of kept directories is dropped,
+// as it travels along with its directory.
+func selectDirectories(nodes []source.Node) []source.Node {
+	var selected []source.Node
+	var current string
+	for _, n := range nodes {
+		if current != "" && isSubPath(current, n.Path) {
+			// Part of an already selected directory.
+			continue
+		}
+
+		if n.Entry == nil || !n.Entry.IsDir() || n.Error != nil {
+			selected = append(selected, n)
+			continue
+		}
+
+		switch n.Response.(type) {
+		case provider.ResponseMovie, provider.ResponseTV, provider.ResponseTVSeason:
+			current = n.Path
+			selected = append(selected, n)
+		}
+	}
+
+	return selected
+}
+
+// isSubPath reports whether path is contained in dir.
+func isSubPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// writer is a function type that performs the actual file operation (symlink, hardlink, copy or move).
 type writer func(string, string) error
 
 // write executes the writer function if Write mode is enabled, otherwise does nothing.
-func (r *renamer) write(src, dst string, fn writer) error {
+// src is the original source path, used for the journal, while realSrc is the path given to the writer.
+func (r *renamer) write(src, realSrc, dst string, fn writer) error {
 	if !r.o.Write || fn == nil {
 		return nil
 	}
 
+	// Replaced destinations are set aside: the journal keeps them until the run is undone,
+	// otherwise they are restored when writing fails, and removed once it succeeded.
+	var replaced string
 	if r.o.Force {
 		if _, err := os.Lstat(dst); err == nil {
-			err = os.Remove(dst)
+			if r.journal != nil {
+				err = r.journal.backup(dst)
+			} else {
+				replaced, err = setAside(dst, time.Now().UTC().Format(runIDFormat))
+			}
 			if err != nil {
 				return fmt.Errorf("failed to remove existing destination %q: %w", dst, err)
 			}
 		}
 	}
 
-	return fn(src, dst)
+	err := fn(realSrc, dst)
+	if err != nil {
+		if replaced != "" {
+			_ = os.Rename(replaced, dst)
+		}
+		return err
+	}
+
+	if replaced != "" {
+		err = os.RemoveAll(replaced)
+		if err != nil {
+			return fmt.Errorf("failed to remove replaced destination %q: %w", replaced, err)
+		}
+	}
+
+	if r.journal == nil {
+		return nil
+	}
+
+	rec := JournalRecord{Op: r.o.RenameMode, Source: src, Destination: dst}
+	if rec.Op == opCopy || rec.Op == opHardlink {
+		rec.Files, err = treeFiles(dst)
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.journal.record(rec)
+}
+
+// mkdirAll creates dir along with any necessary parents, recording them into the journal if enabled.
+func (r *renamer) mkdirAll(dir string) error {
+	if r.journal != nil {
+		return r.journal.mkdirAll(dir)
+	}
+
+	return os.MkdirAll(dir, 0o755) //nolint:gosec
 }
 
 // getSymlinkSrc calculates the relative path from dst to src for creating a symlink.
@@ -350,8 +689,16 @@ func getSymlinkSrc(src, dst string) (string, error) {
 	return realSrc, nil
 }
 
+// copyChunkSize is the amount of data copied between two checks of the context.
+const copyChunkSize = 64 << 20
+
 // copyFile copies a regular file from src to dst.
-func copyFile(src, dst string) error {
+// The copy stops once ctx is done, the partial destination file is then removed.
+func copyFile(ctx context.Context, src, dst string) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	sourceFileStat, err := os.Stat(src)
 	if err != nil {
 		return fmt.Errorf("failed to stat source file %q: %w", src, err)
@@ -372,6 +719,29 @@ func copyFile(src, dst string) error {
 		return fmt.Errorf("failed to create destination file %q: %w", dst, err)
 	}
 	defer destination.Close() //nolint:errcheck
-	_, err = io.Copy(destination, source)
-	return fmt.Errorf("failed to copy from %q to %q: %w", src, dst, err)
+	defer func() {
+		if err != nil {
+			// Do not leave a half-written copy behind.
+			_ = destination.Close()
+			_ = os.Remove(dst)
+		}
+	}()
+
+	// Copy by chunks, so the copy of large files can be interrupted.
+	for {
+		err = ctx.Err()
+		if err != nil {
+			return fmt.Errorf("copy from %q to %q interrupted: %w", src, dst, err)
+		}
+
+		_, err = io.CopyN(destination, source, copyChunkSize)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy from %q to %q: %w", src, dst, err)
+		}
+	}
+
+	return destination.Close()
 }
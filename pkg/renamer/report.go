@@ -0,0 +1,213 @@
+package renamer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+// Output formats of the results.
+const (
+	// OutputFormatText logs the results for humans, this is the default
+	OutputFormatText = "text"
+	// OutputFormatJSON writes the results as indented JSON objects
+	OutputFormatJSON = "json"
+	// OutputFormatJSONL writes the results as JSON lines
+	OutputFormatJSONL = "jsonl"
+)
+
+// OutputFormats lists the supported output formats.
+var OutputFormats = []string{OutputFormatText, OutputFormatJSON, OutputFormatJSONL}
+
+// Status of an entry once executed.
+const (
+	StatusRenamed     = "renamed"
+	StatusExcluded    = "excluded"
+	StatusSkipped     = "skipped"
+	StatusFailed      = "failed"
+	StatusInterrupted = "interrupted"
+	StatusNeedsReview = "needs_review"
+)
+
+// entryRecord is the result of an entry, written for machine-readable output formats.
+type entryRecord struct {
+	Type          string               `json:"type"`
+	Status        string               `json:"status"`
+	DryRun        bool                 `json:"dry_run"`
+	Source        string               `json:"source"`
+	Destination   string               `json:"destination,omitempty"`
+	MediaType     provider.MediaType   `json:"media_type,omitempty"`
+	Provider      string               `json:"provider,omitempty"`
+	ProviderID    int                  `json:"provider_id,omitempty"`
+	ShowID        int                  `json:"show_id,omitempty"`
+	Score         *float64             `json:"score,omitempty"`
+	Confidence    *float64             `json:"confidence,omitempty"`
+	Candidates    []provider.Candidate `json:"candidates,omitempty"`
+	Error         string               `json:"error,omitempty"`
+	ErrorCategory string               `json:"error_category,omitempty"`
+}
+
+// summaryRecord is the summary of a run, written last for machine-readable output formats.
+type summaryRecord struct {
+	Type        string `json:"type"`
+	DryRun      bool   `json:"dry_run"`
+	Directories int    `json:"directories"`
+	Total       int    `json:"total"`
+	Renamed     int    `json:"renamed"`
+	Excluded    int    `json:"excluded"`
+	Skipped     int    `json:"skipped"`
+	Failed      int    `json:"failed"`
+	Interrupted int    `json:"interrupted"`
+	NeedsReview int    `json:"needs_review"`
+}
+
+// report writes one record per entry of the executed plan followed by a summary, when a machine-readable output format is configured.
+func (r *renamer) report(plan *Plan, directories int) error {
+	if r.o.OutputFormat == "" || r.o.OutputFormat == OutputFormatText {
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if r.o.Report != nil {
+		w = r.o.Report
+	}
+
+	encoder := json.NewEncoder(w)
+	if r.o.OutputFormat == OutputFormatJSON {
+		encoder.SetIndent("", "  ")
+	}
+
+	summary := summaryRecord{
+		Type:        "summary",
+		DryRun:      !r.o.Write,
+		Directories: directories,
+		Total:       len(plan.Entries),
+	}
+	for _, e := range plan.Entries {
+		record := entryRecord{
+			Type:        "entry",
+			Status:      entryStatus(e.Error),
+			DryRun:      !r.o.Write,
+			Source:      e.Source,
+			Destination: e.Destination,
+			MediaType:   e.MediaType,
+			Provider:    e.Provider,
+			ProviderID:  e.ProviderID,
+			ShowID:      e.ShowID,
+		}
+		if c, ok := e.MatchCandidate(); ok {
+			record.Score = &c.Score
+			if c.Breakdown != nil {
+				record.Confidence = &c.Breakdown.Confidence
+			}
+		}
+		if e.Error != nil {
+			record.Error = e.Error.Error()
+			record.ErrorCategory = errorCategory(e.Error)
+		}
+		if record.Status == StatusNeedsReview {
+			record.Candidates = e.Candidates[:min(len(e.Candidates), reviewCandidates)]
+		}
+
+		switch record.Status {
+		case StatusRenamed:
+			summary.Renamed++
+		case StatusExcluded:
+			summary.Excluded++
+		case StatusSkipped:
+			summary.Skipped++
+		case StatusFailed:
+			summary.Failed++
+		case StatusInterrupted:
+			summary.Interrupted++
+		case StatusNeedsReview:
+			summary.NeedsReview++
+		}
+
+		err := encoder.Encode(record)
+		if err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	err := encoder.Encode(summary)
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+// entryStatus returns the status of an executed entry from its error.
+// Entries whose destination already exists and ignored entries are skipped,
+// entries which were not processed because the run was interrupted are reported as such,
+// as are entries whose match was not confident enough and need review.
+func entryStatus(err error) string {
+	switch {
+	case err == nil:
+		return StatusRenamed
+	case isInterrupted(err):
+		return StatusInterrupted
+	case errors.Is(err, source.ErrNeedsReview):
+		return StatusNeedsReview
+	case errors.Is(err, ErrDestinationExists), errors.Is(err, source.ErrIgnoredPath):
+		return StatusSkipped
+	case errors.Is(err, source.ErrExcludedPath):
+		return StatusExcluded
+	default:
+		return StatusFailed
+	}
+}
+
+// errorCategory returns the category of an entry error.
+func errorCategory(err error) string {
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	switch {
+	case isInterrupted(err):
+		return "interrupted"
+	case errors.Is(err, source.ErrNeedsReview):
+		return "needs_review"
+	case errors.Is(err, ErrDestinationExists):
+		return "destination_exists"
+	case errors.Is(err, source.ErrExcludedPath):
+		return "excluded"
+	case errors.Is(err, source.ErrIgnoredPath):
+		return "ignored"
+	case errors.Is(err, provider.ErrRetryable):
+		return "retryable"
+	case errors.Is(err, provider.ErrNoResult):
+		return "no_match"
+	case errors.Is(err, ErrSourceChanged):
+		return "source_changed"
+	case errors.Is(err, ErrDuplicatePath):
+		return "duplicate"
+	case errors.As(err, &pathErr), errors.As(err, &linkErr):
+		return "filesystem"
+	default:
+		return "other"
+	}
+}
+
+// MatchCandidate returns the candidate of the match of the entry, when known.
+// The candidate of a season or an episode is its show.
+func (e Entry) MatchCandidate() (provider.Candidate, bool) {
+	id, mediaType := e.ProviderID, e.MediaType
+	if e.ShowID != 0 {
+		id, mediaType = e.ShowID, provider.MediaTypeTV
+	}
+
+	for _, c := range e.Candidates {
+		if c.Provider == e.Provider && c.ID == id && c.MediaType == mediaType {
+			return c, true
+		}
+	}
+
+	return provider.Candidate{}, false
+}
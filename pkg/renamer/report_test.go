@@ -0,0 +1,163 @@
+package renamer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+	"github.com/TheoBrigitte/evansky/pkg/renamer/format"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+// decodeReport returns the entry records of a JSON lines report by source file name, along with its summary.
+func decodeReport(t *testing.T, report []byte) (map[string]entryRecord, summaryRecord) {
+	t.Helper()
+
+	entries := make(map[string]entryRecord)
+	var summary summaryRecord
+	decoder := json.NewDecoder(bytes.NewReader(report))
+	for decoder.More() {
+		var record json.RawMessage
+		err := decoder.Decode(&record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var e entryRecord
+		err = json.Unmarshal(record, &e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Type != "summary" {
+			entries[filepath.Base(e.Source)] = e
+			continue
+		}
+		err = json.Unmarshal(record, &summary)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return entries, summary
+}
+
+func TestReport(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	for _, name := range []string{"The Matrix 1999.mkv", "Dark S01E02.mkv", "Dakr S01E03.mkv", "sample.mkv", "notes.txt"} {
+		writeFile(t, filepath.Join(src, name), name)
+	}
+
+	p := &providertest.Provider{
+		Movies: []providertest.Movie{{ID: 1, Name: "The Matrix", Year: 1999}},
+		Shows:  []providertest.Show{{ID: 10, Name: "Dark", Year: 2017, Seasons: []int{0, 3}}},
+	}
+	var report bytes.Buffer
+	r, err := New([]string{src}, []provider.Interface{p}, Options{
+		Formatter:    format.NewPlexFormatter(),
+		Output:       filepath.Join(root, "out"),
+		OutputFormat: OutputFormatJSONL,
+		Report:       &report,
+		RenameMode:   "symlink",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := r.Plan(t.Context(), source.Options{
+		MediaExts:       []string{"mkv"},
+		ExcludeGlob:     []string{"sample.mkv"},
+		MinConfidence:   0.99,
+		StripComponents: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Execute(t.Context(), plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, summary := decodeReport(t, report.Bytes())
+
+	// Episodes are scored through their show.
+	episode := entries["Dark S01E02.mkv"]
+	if episode.Status != StatusRenamed || episode.MediaType != provider.MediaTypeTVEpisode || episode.ShowID != 10 {
+		t.Errorf("expected episode of show 10 to be renamed, got %+v", episode)
+	}
+	if episode.Score == nil || episode.Confidence == nil || *episode.Confidence != 1 {
+		t.Errorf("expected episode to have the score and confidence of its show, got %v %v", episode.Score, episode.Confidence)
+	}
+
+	movie := entries["The Matrix 1999.mkv"]
+	if movie.Status != StatusRenamed || movie.ProviderID != 1 || movie.ShowID != 0 || movie.Score == nil || movie.Confidence == nil {
+		t.Errorf("expected movie to be renamed with its score, got %+v", movie)
+	}
+
+	review := entries["Dakr S01E03.mkv"]
+	if review.Status != StatusNeedsReview || review.ErrorCategory != "needs_review" || len(review.Candidates) == 0 {
+		t.Errorf("expected misspelled episode to need review with candidates, got %+v", review)
+	}
+	if review.Confidence == nil || *review.Confidence >= 0.99 {
+		t.Errorf("expected confidence of the show needing review, got %v", review.Confidence)
+	}
+
+	if e := entries["sample.mkv"]; e.Status != StatusExcluded || e.ErrorCategory != "excluded" {
+		t.Errorf("expected sample to be excluded, got %+v", e)
+	}
+	if e := entries["notes.txt"]; e.Status != StatusSkipped || e.ErrorCategory != "ignored" {
+		t.Errorf("expected notes to be skipped, got %+v", e)
+	}
+
+	expected := summaryRecord{Type: "summary", DryRun: true, Directories: summary.Directories, Total: 5, Renamed: 2, Excluded: 1, Skipped: 1, NeedsReview: 1}
+	if summary != expected {
+		t.Errorf("expected summary %+v, got %+v", expected, summary)
+	}
+}
+
+func TestEntryStatus(t *testing.T) {
+	testCases := []struct {
+		err      error
+		status   string
+		category string
+	}{
+		{err: nil, status: StatusRenamed, category: ""},
+		{err: fmt.Errorf("not renamed: %w", context.Canceled), status: StatusInterrupted, category: "interrupted"},
+		{err: context.DeadlineExceeded, status: StatusInterrupted, category: "interrupted"},
+		{err: fmt.Errorf("%w: confidence 0.40", source.ErrNeedsReview), status: StatusNeedsReview, category: "needs_review"},
+		{err: fmt.Errorf("%w: a.mkv", ErrDestinationExists), status: StatusSkipped, category: "destination_exists"},
+		{err: fmt.Errorf("%w: unknown file type", source.ErrIgnoredPath), status: StatusSkipped, category: "ignored"},
+		{err: fmt.Errorf("%w by glob", source.ErrExcludedPath), status: StatusExcluded, category: "excluded"},
+		{err: fmt.Errorf("failed to find media: %w", provider.ErrRetryable), status: StatusFailed, category: "retryable"},
+		{err: fmt.Errorf("failed to find media: %w", provider.ErrNoResult), status: StatusFailed, category: "no_match"},
+		{err: fmt.Errorf("%w: size changed", ErrSourceChanged), status: StatusFailed, category: "source_changed"},
+		{err: fmt.Errorf("%w: could not deduplicate destination", ErrDuplicatePath), status: StatusFailed, category: "duplicate"},
+		{err: &fs.PathError{Op: "open", Path: "a.mkv", Err: fs.ErrPermission}, status: StatusFailed, category: "filesystem"},
+		{err: &os.LinkError{Op: "link", Old: "a.mkv", New: "b.mkv", Err: fs.ErrExist}, status: StatusFailed, category: "filesystem"},
+		{err: errors.New("no components"), status: StatusFailed, category: "other"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("entryStatus_%03d", i), func(t *testing.T) {
+			status := entryStatus(tc.err)
+			if status != tc.status {
+				t.Errorf("For input '%v', expected '%s' but got '%s'", tc.err, tc.status, status)
+			}
+			if tc.err == nil {
+				return
+			}
+			category := errorCategory(tc.err)
+			if category != tc.category {
+				t.Errorf("For input '%v', expected '%s' but got '%s'", tc.err, tc.category, category)
+			}
+		})
+	}
+}
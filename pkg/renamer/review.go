@@ -0,0 +1,162 @@
+package renamer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+// CompanionsOf returns the indexes of the companion entries of the entry at index.
+// Companions are only known for plans which were not loaded from a file.
+func (p *Plan) CompanionsOf(index int) []int {
+	if index < 0 || index >= len(p.Entries) {
+		return nil
+	}
+
+	companions := make([]int, 0, p.Entries[index].companions)
+	for i := index + 1; i <= index+p.Entries[index].companions; i++ {
+		companions = append(companions, i)
+	}
+
+	return companions
+}
+
+// Rematch formats the entry at index again, using its candidate at candidate index instead of the best match.
+// When a TV show is chosen for a season or an episode, the season or episode is looked up again within that show.
+// Companions of the entry follow it.
+func (r *renamer) Rematch(ctx context.Context, plan *Plan, index, candidate int) error {
+	if index < 0 || index >= len(plan.Entries) {
+		return fmt.Errorf("entry %d not found", index)
+	}
+	e := plan.Entries[index]
+	if e.node == nil {
+		return fmt.Errorf("entry %d cannot be rematched", index)
+	}
+	if candidate < 0 || candidate >= len(e.Candidates) || e.Candidates[candidate].Response == nil {
+		return fmt.Errorf("candidate %d not found for entry %d", candidate, index)
+	}
+
+	// Candidates are named in the query language, switch to the destination language.
+	resp, err := e.Candidates[candidate].Response.InLanguage(ctx, provider.Request{DestinationLanguage: r.sourceOptions.Language})
+	if err != nil {
+		return fmt.Errorf("failed to get candidate %d: %w", candidate, err)
+	}
+
+	node := *e.node
+	node.Response = resp
+	node.Error = nil
+	node.Episodes = nil
+	if tv, ok := resp.(provider.ResponseTV); ok && isTVChild(node) {
+		node, err = source.FindTVChild(ctx, r.providers, r.sourceOptions, node, tv)
+		if err != nil {
+			return fmt.Errorf("failed to find entry %d in candidate %d: %w", index, candidate, err)
+		}
+	}
+
+	output := r.o.Output
+	if output == "" {
+		output = filepath.Dir(filepath.Clean(node.Path))
+	}
+
+	r.forget(plan, index)
+	entry, dir := r.generateEntry(node, output)
+	entry.Candidates = e.Candidates
+	entry.node = &node
+	entry.companions = e.companions
+	plan.Entries[index] = entry
+	if dir != "" && entry.Error == nil {
+		plan.Directories = append(plan.Directories, dir)
+	}
+	r.relocateCompanions(plan, index)
+
+	return entry.Error
+}
+
+// isTVChild returns whether n is about a season or an episode rather than a whole show:
+// files are episodes, and directories are seasons when their name holds a season or an episode number.
+func isTVChild(n source.Node) bool {
+	return !n.Entry.IsDir() || n.Info.Season > 0 || n.Info.Episode > 0
+}
+
+// Override sets the destination of the entry at index, regardless of any match.
+// Companions of the entry follow it.
+func (r *renamer) Override(plan *Plan, index int, destination string) error {
+	if index < 0 || index >= len(plan.Entries) {
+		return fmt.Errorf("entry %d not found", index)
+	}
+	if destination == "" {
+		return fmt.Errorf("destination is required")
+	}
+	destination = filepath.Clean(destination)
+
+	e := plan.Entries[index]
+	if e.Source == destination {
+		return fmt.Errorf("source and destination are the same")
+	}
+	for i, other := range plan.Entries {
+		if i != index && other.Error == nil && other.Destination == destination {
+			return fmt.Errorf("%w: destination %s", ErrDuplicatePath, destination)
+		}
+	}
+
+	// Record source state, it may be missing when the entry failed to match.
+	info, err := os.Lstat(e.Source)
+	if err != nil {
+		return fmt.Errorf("failed to read source info: %w", err)
+	}
+
+	r.forget(plan, index)
+	e.Destination = destination
+	e.Error = nil
+	e.Size = info.Size()
+	e.ModTime = info.ModTime()
+	plan.Entries[index] = e
+	r.files[e.Source] = destination
+	plan.Directories = append(plan.Directories, filepath.Dir(destination))
+	r.relocateCompanions(plan, index)
+
+	return nil
+}
+
+// forget drops the destinations of the entry at index and of its companions.
+func (r *renamer) forget(plan *Plan, index int) {
+	delete(r.files, plan.Entries[index].Source)
+	for _, i := range plan.CompanionsOf(index) {
+		delete(r.files, plan.Entries[i].Source)
+	}
+}
+
+// relocateCompanions generates the companion entries of the entry at index again, next to its destination.
+// Directories no longer used by any entry are dropped.
+func (r *renamer) relocateCompanions(plan *Plan, index int) {
+	media := plan.Entries[index]
+	if media.node != nil {
+		for n, i := range plan.CompanionsOf(index) {
+			plan.Entries[i] = r.generateCompanionEntry(media.node.Companions[n], *media.node, media)
+		}
+	}
+
+	plan.pruneDirectories()
+}
+
+// pruneDirectories sorts the directories of the plan and drops the ones which are not the parent of any entry destination.
+func (p *Plan) pruneDirectories() {
+	used := make(map[string]struct{})
+	for _, e := range p.Entries {
+		if e.Error == nil && e.Destination != "" {
+			used[filepath.Dir(e.Destination)] = struct{}{}
+		}
+	}
+
+	slices.Sort(p.Directories)
+	p.Directories = slices.Compact(p.Directories)
+	p.Directories = slices.DeleteFunc(p.Directories, func(dir string) bool {
+		_, ok := used[dir]
+		return !ok
+	})
+}
@@ -0,0 +1,186 @@
+package renamer
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+	"github.com/TheoBrigitte/evansky/pkg/renamer/format"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+func TestRematchEpisode(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src", "Dakr S01E02.mkv")
+	writeFile(t, src, "episode")
+
+	p := &providertest.Provider{
+		Shows: []providertest.Show{
+			{ID: 1, Name: "Dark", Year: 2017, Seasons: []int{0, 3}},
+			{ID: 2, Name: "Dark Matter", Year: 2015, Seasons: []int{0, 1}},
+		},
+	}
+	r, err := New([]string{src}, []provider.Interface{p}, Options{
+		Formatter:  format.NewPlexFormatter(),
+		Output:     filepath.Join(root, "out"),
+		Report:     io.Discard,
+		RenameMode: "move",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The misspelled show needs review, it is reported with the show candidates rather than an episode.
+	plan, err := r.Plan(t.Context(), source.Options{MediaExts: []string{"mkv"}, MinConfidence: 0.99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(plan.Entries))
+	}
+	e := plan.Entries[0]
+	if !errors.Is(e.Error, source.ErrNeedsReview) {
+		t.Fatalf("expected entry to need review, got %v", e.Error)
+	}
+	candidate := func(name string) int {
+		i := slices.IndexFunc(e.Candidates, func(c provider.Candidate) bool { return c.Name == name })
+		if i < 0 {
+			t.Fatalf("candidate %q not found in %v", name, e.Candidates)
+		}
+		return i
+	}
+
+	// The chosen show lacks the episode, the entry is left as is.
+	err = r.Rematch(t.Context(), plan, 0, candidate("Dark Matter"))
+	if err == nil {
+		t.Fatal("expected rematch to a show without the episode to fail")
+	}
+	if !errors.Is(plan.Entries[0].Error, source.ErrNeedsReview) {
+		t.Errorf("expected entry to still need review, got %v", plan.Entries[0].Error)
+	}
+
+	// The episode is looked up within the chosen show.
+	err = r.Rematch(t.Context(), plan, 0, candidate("Dark"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := filepath.Join(root, "out", "Dark (2017) {test-1}", "Season 01", "Dark (2017) - s01e02 - Episode 2.mkv")
+	if plan.Entries[0].Destination != expected {
+		t.Errorf("expected destination %q, got %q", expected, plan.Entries[0].Destination)
+	}
+	if plan.Entries[0].MediaType != provider.MediaTypeTVEpisode {
+		t.Errorf("expected an episode, got %s", plan.Entries[0].MediaType)
+	}
+}
+
+func TestRematchAcceptedEpisode(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src", "Dark S01E02.mkv")
+	writeFile(t, src, "episode")
+
+	p := &providertest.Provider{
+		Shows: []providertest.Show{
+			{ID: 1, Name: "Dark", Year: 2017, Seasons: []int{0, 3}},
+			{ID: 2, Name: "Dark Matter", Year: 2015, Seasons: []int{0, 3}},
+		},
+	}
+	r, err := New([]string{src}, []provider.Interface{p}, Options{
+		Formatter:  format.NewPlexFormatter(),
+		Output:     filepath.Join(root, "out"),
+		Report:     io.Discard,
+		RenameMode: "move",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := r.Plan(t.Context(), source.Options{MediaExts: []string{"mkv"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The episode is accepted, it carries the candidates of its show.
+	e := plan.Entries[0]
+	if e.Error != nil || e.ShowID != 1 {
+		t.Fatalf("expected episode of show 1 to be accepted, got %v %d", e.Error, e.ShowID)
+	}
+	c, ok := e.MatchCandidate()
+	if !ok || c.ID != 1 || c.Breakdown == nil || c.Breakdown.Confidence != 1 {
+		t.Fatalf("expected the show to be the match candidate with its confidence, got %+v %t", c, ok)
+	}
+
+	candidate := slices.IndexFunc(e.Candidates, func(c provider.Candidate) bool { return c.Name == "Dark Matter" })
+	if candidate < 0 {
+		t.Fatalf("expected other shows among the candidates, got %v", e.Candidates)
+	}
+	err = r.Rematch(t.Context(), plan, 0, candidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e = plan.Entries[0]
+	expected := filepath.Join(root, "out", "Dark Matter (2015) {test-2}", "Season 01", "Dark Matter (2015) - s01e02 - Episode 2.mkv")
+	if e.Destination != expected {
+		t.Errorf("expected destination %q, got %q", expected, e.Destination)
+	}
+	if c, ok := e.MatchCandidate(); !ok || e.ShowID != 2 || c.ID != 2 {
+		t.Errorf("expected the chosen show to be the match candidate, got %+v %t", c, ok)
+	}
+}
+
+func TestSkipExistingCompanion(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeFile(t, filepath.Join(src, "The Matrix 1999.mkv"), "movie")
+	writeFile(t, filepath.Join(src, "The Matrix 1999.nfo"), "info")
+	writeFile(t, filepath.Join(root, "out", "Other", "Other.nfo"), "other")
+
+	p := &providertest.Provider{
+		Movies: []providertest.Movie{{ID: 1, Name: "The Matrix", Year: 1999}},
+	}
+	r, err := New([]string{src}, []provider.Interface{p}, Options{
+		Formatter:    format.NewPlexFormatter(),
+		Output:       filepath.Join(root, "out"),
+		Report:       io.Discard,
+		RenameMode:   "move",
+		SkipExisting: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := r.Plan(t.Context(), source.Options{MediaExts: []string{"mkv"}, CompanionExts: []string{"nfo"}, StripComponents: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Entries) != 2 || plan.Entries[0].Error != nil || plan.Entries[1].Error != nil {
+		t.Fatalf("expected movie and its companion to be renamed, got %+v", plan.Entries)
+	}
+
+	// The companion follows its media into a directory where its destination exists.
+	err = r.Override(plan, 0, filepath.Join(root, "out", "Other", "Other.mkv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := plan.Entries[1]; !errors.Is(e.Error, ErrDestinationExists) {
+		t.Errorf("expected companion to be skipped, got %q %v", e.Destination, e.Error)
+	}
+
+	// Companions of skipped media are skipped too.
+	writeFile(t, filepath.Join(root, "out", "The Matrix (1999) {test-1}", "other.mkv"), "other")
+	r, err = New([]string{src}, []provider.Interface{p}, r.o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err = r.Plan(t.Context(), source.Options{MediaExts: []string{"mkv"}, CompanionExts: []string{"nfo"}, StripComponents: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Entries) != 2 || !errors.Is(plan.Entries[0].Error, ErrDestinationExists) || plan.Entries[1].Error == nil {
+		t.Errorf("expected movie and its companion to be skipped, got %+v", plan.Entries)
+	}
+}
@@ -0,0 +1,76 @@
+package renamer
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+// stackKey identifies the files of a multi-part movie.
+type stackKey struct {
+	dir        string
+	nodeType   source.NodeType
+	provider   string
+	providerID int
+	edition    string
+}
+
+// stackParts groups the files of multi-part movies (CD1, CD2, etc.) into a single logical movie.
+// Files of the same movie, edition and directory are stacked together, they are named after their part number.
+// A part marker on a file which is not stacked with any other is not a multi-part movie, its part is cleared.
+// Stacks with duplicate or missing part numbers cannot be named reliably, their files are set in error.
+func stackParts(nodes []source.Node) []source.Node {
+	stacks := make(map[stackKey][]int)
+	for i, n := range nodes {
+		if n.Error != nil || n.Entry == nil || n.Entry.IsDir() {
+			continue
+		}
+		m, ok := n.Response.(provider.ResponseMovie)
+		if !ok {
+			continue
+		}
+
+		key := stackKey{
+			dir:        filepath.Dir(n.Path),
+			nodeType:   n.Type,
+			provider:   m.GetProvider(),
+			providerID: m.GetID(),
+			edition:    n.Info.Edition,
+		}
+		stacks[key] = append(stacks[key], i)
+	}
+
+	for _, stack := range stacks {
+		if len(stack) == 1 {
+			nodes[stack[0]].Info.Part = 0
+			continue
+		}
+
+		parts := make(map[int]string)
+		for _, i := range stack {
+			part := nodes[i].Info.Part
+			if part == 0 {
+				continue
+			}
+			if other, exists := parts[part]; exists {
+				nodes[i].Error = fmt.Errorf("duplicate part %d, also found in %q", part, other)
+				continue
+			}
+			parts[part] = nodes[i].Path
+		}
+		if len(parts) == 0 {
+			// Not a multi-part movie, e.g. several copies of the same movie.
+			continue
+		}
+
+		for _, i := range stack {
+			if nodes[i].Info.Part == 0 {
+				nodes[i].Error = fmt.Errorf("missing part number, other files of this movie are stacked")
+			}
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,130 @@
+package renamer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// hardlink creates dst as a hard link to src.
+// Directories cannot be hard linked, their tree is recreated with hard links to every file instead,
+// it is removed from dst when linking fails.
+func hardlink(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat source %q: %w", src, err)
+	}
+
+	if info.IsDir() {
+		return copyTree(src, dst, os.Link)
+	}
+
+	return os.Link(src, dst)
+}
+
+// copyPath copies src to dst, directories are copied recursively.
+// A directory is removed from dst when its copy fails or is interrupted.
+func copyPath(ctx context.Context, src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat source %q: %w", src, err)
+	}
+
+	if info.IsDir() {
+		return copyTree(src, dst, func(src, dst string) error { return copyFile(ctx, src, dst) })
+	}
+
+	return copyFile(ctx, src, dst)
+}
+
+// copyTree recreates the directory tree of src into dst, using fn to write every regular file.
+// Symlinks are recreated with the same target.
+// The partial tree is removed when writing fails, dst is left alone when it could not be created.
+func copyTree(src, dst string, fn writer) error {
+	created := false
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			err = os.Mkdir(target, info.Mode().Perm())
+			if err != nil {
+				return fmt.Errorf("failed to create directory %q: %w", target, err)
+			}
+			created = true
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read link %q: %w", path, err)
+			}
+			err = os.Symlink(link, target)
+			if err != nil {
+				return fmt.Errorf("failed to create link %q: %w", target, err)
+			}
+		case info.Mode().IsRegular():
+			err = fn(path, target)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+
+		return nil
+	})
+	if err != nil && created {
+		_ = os.RemoveAll(dst)
+	}
+
+	return err
+}
+
+// treeFiles returns the paths of the entries within the directory dst, relative to it and parents first.
+// It returns nil when dst is not a directory.
+func treeFiles(dst string) ([]string, error) {
+	info, err := os.Lstat(dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat destination %q: %w", dst, err)
+	}
+	if !info.IsDir() {
+		return nil, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dst {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dst, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %q: %w", dst, err)
+	}
+
+	return files, nil
+}
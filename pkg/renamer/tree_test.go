@@ -0,0 +1,189 @@
+package renamer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates a directory holding a file, a file in a sub-directory and a link to the first file.
+func writeTree(t *testing.T, dir string) {
+	t.Helper()
+
+	writeFile(t, filepath.Join(dir, "a.mkv"), "a")
+	writeFile(t, filepath.Join(dir, "Extras", "b.mkv"), "b")
+	err := os.Symlink("a.mkv", filepath.Join(dir, "link.mkv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertTree ensures dir holds the tree created by writeTree.
+func assertTree(t *testing.T, dir string) {
+	t.Helper()
+
+	if readFile(t, filepath.Join(dir, "a.mkv")) != "a" || readFile(t, filepath.Join(dir, "Extras", "b.mkv")) != "b" {
+		t.Errorf("expected directory tree to be copied into %q", dir)
+	}
+	if link, err := os.Readlink(filepath.Join(dir, "link.mkv")); err != nil || link != "a.mkv" {
+		t.Errorf("expected link to be recreated, got %q %v", link, err)
+	}
+}
+
+func TestCopyPath(t *testing.T) {
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	writeTree(t, src)
+
+	err := copyPath(t.Context(), src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTree(t, dst)
+	assertTree(t, src)
+}
+
+func TestCopyPathRollback(t *testing.T) {
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	writeTree(t, src)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err := copyPath(ctx, src, dst)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected copy to be interrupted, got %v", err)
+	}
+	if _, err := os.Lstat(dst); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected partial copy to be removed, got %v", err)
+	}
+	assertTree(t, src)
+}
+
+func TestCopyTreeFailure(t *testing.T) {
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	writeTree(t, src)
+
+	failure := errors.New("disk full")
+	var written []string
+	err := copyTree(src, dst, func(src, dst string) error {
+		if filepath.Base(src) == "a.mkv" {
+			return failure
+		}
+		written = append(written, filepath.Base(src))
+		return copyFile(t.Context(), src, dst)
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected copy to fail with %v, got %v", failure, err)
+	}
+	// Entries are copied in lexical order, Extras comes first.
+	if len(written) != 1 || written[0] != "b.mkv" {
+		t.Errorf("expected copy to stop at the failure, got %v", written)
+	}
+	// The partial tree is removed.
+	if _, err := os.Lstat(dst); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected partial copy to be removed, got %v", err)
+	}
+
+	// The destination must not exist yet, it is then left alone.
+	err = copyTree(src, src, os.Link)
+	if err == nil {
+		t.Error("expected copy into an existing directory to fail")
+	}
+	assertTree(t, src)
+}
+
+func TestHardlinkDirectory(t *testing.T) {
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	writeTree(t, src)
+
+	err := hardlink(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTree(t, dst)
+
+	for _, name := range []string{"a.mkv", filepath.Join("Extras", "b.mkv")} {
+		srcInfo, err := os.Stat(filepath.Join(src, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dstInfo, err := os.Stat(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !os.SameFile(srcInfo, dstInfo) {
+			t.Errorf("expected %q to be hard linked", name)
+		}
+	}
+}
+
+func TestMoveDirectoryAcrossDevices(t *testing.T) {
+	crossDevice(t)
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	writeTree(t, src)
+
+	err := moveFile(t.Context(), src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTree(t, dst)
+	if _, err := os.Lstat(src); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected source to be removed, got %v", err)
+	}
+}
+
+func TestMoveDirectoryInterrupted(t *testing.T) {
+	crossDevice(t)
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	writeTree(t, src)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err := moveFile(ctx, src, dst)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected move to be interrupted, got %v", err)
+	}
+	if _, err := os.Lstat(dst); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected partial copy to be removed, got %v", err)
+	}
+	assertTree(t, src)
+}
+
+func TestForceReplacesDirectory(t *testing.T) {
+	root := t.TempDir()
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	writeTree(t, src)
+	writeFile(t, filepath.Join(dst, "old.mkv"), "old")
+
+	r := &renamer{o: Options{Force: true, RenameMode: "copy", Write: true}}
+
+	// Without a journal, a failed write restores the replaced directory.
+	failure := errors.New("copy failed")
+	err := r.write(src, src, dst, func(string, string) error { return failure })
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected write to fail, got %v", err)
+	}
+	if readFile(t, filepath.Join(dst, "old.mkv")) != "old" {
+		t.Error("expected replaced directory to be restored")
+	}
+
+	// A successful write removes it.
+	err = r.write(src, src, dst, func(src, dst string) error { return copyPath(t.Context(), src, dst) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTree(t, dst)
+	if _, err := os.Lstat(filepath.Join(dst, "old.mkv")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected replaced directory to be gone, got %v", err)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(root, "*"+backupExt)); len(backups) > 0 {
+		t.Errorf("expected replaced directory to be removed, got %v", backups)
+	}
+}
@@ -0,0 +1,361 @@
+package renamer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Runs returns the identifiers of the runs recorded in dir which have not been undone yet,
+// sorted from the oldest to the most recent.
+func Runs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal directory %q: %w", dir, err)
+	}
+
+	var runs []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, journalExt) || strings.HasSuffix(name, undoneJournalExt) {
+			continue
+		}
+		runs = append(runs, strings.TrimSuffix(name, journalExt))
+	}
+	slices.Sort(runs)
+
+	return runs, nil
+}
+
+// ReadJournal returns the records of the run identified by runID.
+func ReadJournal(dir, runID string) ([]JournalRecord, error) {
+	f, err := os.Open(filepath.Join(dir, runID+journalExt)) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal of run %q: %w", runID, err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	var records []JournalRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec JournalRecord
+		err := json.Unmarshal(scanner.Bytes(), &rec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode journal of run %q: %w", runID, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal of run %q: %w", runID, err)
+	}
+
+	return records, nil
+}
+
+// Undo reverses the run identified by runID, or the most recent run when runID is empty.
+// Records are processed in reverse order: written files are removed, moved files are moved back,
+// replaced destinations are restored where possible and created directories are removed when empty.
+// Nothing is changed unless write is true.
+// Once ctx is done, or when some records fail, remaining records are left as is and the run can be undone again later,
+// records already reversed by a previous attempt are then skipped.
+func Undo(ctx context.Context, dir, runID string, write bool) error {
+	// log output prefix
+	prefix := ""
+	if !write {
+		prefix = "[dry-run] "
+	}
+
+	if runID == "" {
+		runs, err := Runs(dir)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			return fmt.Errorf("no run to undo in %q", dir)
+		}
+		runID = runs[len(runs)-1]
+	}
+
+	records, err := ReadJournal(dir, runID)
+	if err != nil {
+		return err
+	}
+
+	// Records undone by a previous attempt are skipped, as undoing them again would fail.
+	statePath := filepath.Join(dir, runID+undoStateExt)
+	undone, err := readUndoState(statePath)
+	if err != nil {
+		return err
+	}
+	state := &undoState{path: statePath}
+	defer state.close() //nolint:errcheck
+
+	undoneCount := 0
+	errorsCount := 0
+	for i, rec := range slices.Backward(records) {
+		if ctx.Err() != nil {
+			log.Warn().Str("operation", rec.Op).Str("destination", rec.Destination).Msgf("%snot undone, interrupted", prefix)
+			errorsCount++
+			continue
+		}
+
+		if undone[i] {
+			log.Debug().Str("operation", rec.Op).Str("destination", rec.Destination).Msgf("%salready undone", prefix)
+			undoneCount++
+			continue
+		}
+
+		err := undoRecord(ctx, rec, write)
+		if err != nil {
+			log.Err(err).Str("operation", rec.Op).Str("destination", rec.Destination).Msgf("%sundo failed", prefix)
+			errorsCount++
+			continue
+		}
+
+		if write {
+			err = state.done(i)
+			if err != nil {
+				return err
+			}
+		}
+
+		log.Info().Str("operation", rec.Op).Str("source", rec.Source).Str("destination", rec.Destination).Msgf("%sundone", prefix)
+		undoneCount++
+	}
+
+	e := log.Info()
+	if errorsCount > 0 {
+		e = log.Warn()
+	}
+	e.Str("run", runID).Msgf("%sundone %d/%d operation(s)", prefix, undoneCount, len(records))
+
+	if !write || errorsCount > 0 {
+		return nil
+	}
+
+	// Mark the run as undone, so it is not picked up again.
+	err = os.Rename(filepath.Join(dir, runID+journalExt), filepath.Join(dir, runID+undoneJournalExt))
+	if err != nil {
+		return fmt.Errorf("failed to mark run %q as undone: %w", runID, err)
+	}
+	_ = state.close()
+	_ = os.Remove(statePath)
+
+	return nil
+}
+
+// undoState records the journal records which have been undone, by their index, one per line.
+// The file is only created once a record is undone.
+type undoState struct {
+	path string
+	file *os.File
+}
+
+// readUndoState returns the indexes of the records undone according to the state file at path.
+func readUndoState(path string) (map[int]bool, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read undo state %q: %w", path, err)
+	}
+
+	undone := map[int]bool{}
+	for _, line := range strings.Fields(string(b)) {
+		i, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode undo state %q: %w", path, err)
+		}
+		undone[i] = true
+	}
+
+	return undone, nil
+}
+
+// done records the record at index i as undone.
+func (s *undoState) done(i int) error {
+	if s.file == nil {
+		f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("failed to open undo state %q: %w", s.path, err)
+		}
+		s.file = f
+	}
+
+	_, err := fmt.Fprintln(s.file, i)
+	if err != nil {
+		return fmt.Errorf("failed to write undo state %q: %w", s.path, err)
+	}
+
+	return nil
+}
+
+// close closes the state file, if it was opened.
+func (s *undoState) close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// undoRecord reverses a single journal record.
+func undoRecord(ctx context.Context, rec JournalRecord, write bool) error {
+	switch rec.Op {
+	case opMkdir:
+		if !write {
+			return nil
+		}
+		err := os.Remove(rec.Destination)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove directory, it may contain other files: %w", err)
+		}
+		return nil
+
+	case opSymlink:
+		info, err := os.Lstat(rec.Destination)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("destination is not a symlink anymore")
+		}
+		return remove(rec.Destination, write)
+
+	case opHardlink:
+		srcInfo, err := os.Lstat(rec.Source)
+		if err != nil {
+			return err
+		}
+		dstInfo, err := os.Lstat(rec.Destination)
+		if err != nil {
+			return err
+		}
+		if dstInfo.IsDir() {
+			// Directory tree recreated with hard links.
+			return removeTree(rec, write)
+		}
+		if !os.SameFile(srcInfo, dstInfo) {
+			return fmt.Errorf("destination is not a link to the source anymore")
+		}
+		return remove(rec.Destination, write)
+
+	case opCopy:
+		dstInfo, err := os.Lstat(rec.Destination)
+		if err != nil {
+			return err
+		}
+		if dstInfo.IsDir() {
+			return removeTree(rec, write)
+		}
+		return remove(rec.Destination, write)
+
+	case opMove:
+		_, err := os.Lstat(rec.Destination)
+		if err != nil {
+			return err
+		}
+		_, err = os.Lstat(rec.Source)
+		if err == nil {
+			return fmt.Errorf("source %q already exists", rec.Source)
+		}
+		if !write {
+			return nil
+		}
+		err = os.MkdirAll(filepath.Dir(rec.Source), 0o755) //nolint:gosec
+		if err != nil {
+			return err
+		}
+		return moveFile(ctx, rec.Destination, rec.Source)
+
+	case opReplace:
+		if rec.Backup == "" && rec.LinkTarget == "" {
+			return fmt.Errorf("replaced destination was not saved and cannot be restored")
+		}
+		if !write {
+			return nil
+		}
+		_, err := os.Lstat(rec.Destination)
+		if err == nil {
+			return fmt.Errorf("destination %q already exists", rec.Destination)
+		}
+		if rec.LinkTarget != "" {
+			return os.Symlink(rec.LinkTarget, rec.Destination)
+		}
+		return moveFile(ctx, rec.Backup, rec.Destination)
+	}
+
+	return fmt.Errorf("unknown operation %q", rec.Op)
+}
+
+// remove removes path if write is true.
+func remove(path string, write bool) error {
+	if !write {
+		return nil
+	}
+
+	return os.Remove(path)
+}
+
+// removeTree removes the files written within the directory of rec, then its directories when they are empty, if write is true.
+// Files added afterwards are kept along with their directories, as are hard linked files which are not links to their source anymore.
+func removeTree(rec JournalRecord, write bool) error {
+	var kept []string
+	for _, rel := range slices.Backward(rec.Files) {
+		path := filepath.Join(rec.Destination, rel)
+		info, err := os.Lstat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Already removed.
+				continue
+			}
+			return err
+		}
+
+		if info.Mode().IsRegular() && rec.Op == opHardlink {
+			srcInfo, err := os.Lstat(filepath.Join(rec.Source, rel))
+			if err != nil || !os.SameFile(srcInfo, info) {
+				kept = append(kept, rel)
+				continue
+			}
+		}
+
+		if !write {
+			continue
+		}
+		err = os.Remove(path)
+		if err != nil && !info.IsDir() {
+			return err
+		}
+		if err != nil {
+			// Directories holding other files are kept.
+			kept = append(kept, rel)
+		}
+	}
+
+	if len(kept) > 0 {
+		return fmt.Errorf("failed to remove %s, it may contain other files", strings.Join(kept, ", "))
+	}
+
+	err := remove(rec.Destination, write)
+	if err != nil {
+		return fmt.Errorf("failed to remove directory, it may contain other files: %w", err)
+	}
+
+	return nil
+}
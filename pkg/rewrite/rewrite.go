@@ -0,0 +1,203 @@
+// Package rewrite provides rewrites of search queries, tried in turn when a query yields poor matches.
+package rewrite
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Rule is a named rewrite of a query.
+// Apply returns the query unchanged when the rule does not apply.
+type Rule struct {
+	Name  string
+	Apply func(string) string
+}
+
+// Query is a rewritten query, along with the name of the rule which produced it.
+type Query struct {
+	Rule  string
+	Query string
+}
+
+// Rules lists the rewrites in the order they are tried.
+var Rules = []Rule{
+	{Name: "drop-article", Apply: dropArticle},
+	{Name: "roman-to-arabic", Apply: romanToArabic},
+	{Name: "arabic-to-roman", Apply: arabicToRoman},
+	{Name: "ampersand", Apply: swapAmpersand},
+	{Name: "drop-subtitle", Apply: dropSubtitle},
+	{Name: "drop-trailing-name", Apply: dropTrailingName},
+	{Name: "transliterate", Apply: transliterate},
+}
+
+// Expand returns the rewrites of query, in the order of Rules.
+// Rewrites leaving the query unchanged, empty or equal to a previous one are skipped.
+func Expand(query string) []Query {
+	seen := map[string]bool{normalize(query): true}
+
+	var queries []Query
+	for _, r := range Rules {
+		q := strings.TrimSpace(r.Apply(query))
+		key := normalize(q)
+		if q == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		queries = append(queries, Query{Rule: r.Name, Query: q})
+	}
+
+	return queries
+}
+
+// normalize returns s in lower case with its words separated by a single space.
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// articles are the leading articles dropped from queries, in lower case.
+var articles = []string{
+	"the", "a", "an",
+	"le", "la", "les", "l'", "l’",
+	"der", "die", "das", "ein", "eine",
+	"el", "los", "las", "il", "lo", "gli",
+	"un", "une", "una",
+}
+
+// dropArticle removes the leading article of query, e.g. "The Matrix" becomes "Matrix".
+func dropArticle(query string) string {
+	for _, a := range articles {
+		// The prefix of query itself is compared, lowering query may change its length, e.g. "İ".
+		if len(query) < len(a) || !strings.EqualFold(query[:len(a)], a) {
+			continue
+		}
+
+		rest := query[len(a):]
+		if strings.HasSuffix(a, "'") || strings.HasSuffix(a, "’") {
+			// Elided articles are directly followed by the word, e.g. "L'Odyssée".
+			return rest
+		}
+		if strings.HasPrefix(rest, " ") && strings.TrimSpace(rest) != "" {
+			return strings.TrimSpace(rest)
+		}
+	}
+
+	return query
+}
+
+// romanNumerals are the numerals converted between roman and arabic forms, indexed by their value.
+var romanNumerals = []string{"", "I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X", "XI", "XII", "XIII", "XIV", "XV", "XVI", "XVII", "XVIII", "XIX", "XX"}
+
+// romanToArabic converts roman numerals words of query to arabic numerals, e.g. "Rocky II" becomes "Rocky 2".
+// The single "I" is left alone, as it is more often a word than a number.
+func romanToArabic(query string) string {
+	return replaceWords(query, func(word string) string {
+		upper := strings.ToUpper(word)
+		for value, numeral := range romanNumerals {
+			if value > 1 && upper == numeral {
+				return strconv.Itoa(value)
+			}
+		}
+		return word
+	})
+}
+
+// arabicToRoman converts arabic numerals words of query to roman numerals, e.g. "Rocky 2" becomes "Rocky II".
+func arabicToRoman(query string) string {
+	return replaceWords(query, func(word string) string {
+		value, err := strconv.Atoi(word)
+		if err != nil || value < 2 || value >= len(romanNumerals) {
+			return word
+		}
+		return romanNumerals[value]
+	})
+}
+
+// replaceWords returns query with each of its space separated words replaced by replace.
+func replaceWords(query string, replace func(string) string) string {
+	words := strings.Split(query, " ")
+	for i, w := range words {
+		words[i] = replace(w)
+	}
+
+	return strings.Join(words, " ")
+}
+
+var andRegex = regexp.MustCompile(`(?i)\band\b`)
+
+// swapAmpersand replaces "&" with "and" in query, or "and" with "&" when there is no ampersand.
+func swapAmpersand(query string) string {
+	if strings.Contains(query, "&") {
+		return strings.ReplaceAll(query, "&", "and")
+	}
+
+	return andRegex.ReplaceAllString(query, "&")
+}
+
+// dropSubtitle removes the text after the first ":" or " - " of query, e.g. "Alien: Covenant" becomes "Alien".
+func dropSubtitle(query string) string {
+	for _, sep := range []string{":", " - "} {
+		if before, _, ok := strings.Cut(query, sep); ok {
+			query = before
+		}
+	}
+
+	return query
+}
+
+// nameWordRegex matches a capitalized word of a name, e.g. "Kenneth", "O'Brien", "Jean-Pierre" or an initial.
+var nameWordRegex = regexp.MustCompile(`^(\p{Lu}\p{Ll}*([-'’]\p{Lu})?\p{Ll}+|\p{Lu}\.)$`)
+
+// dropTrailingName removes a name set apart after the title of query, such as the director's,
+// e.g. "Hamlet (Kenneth Branagh)" or "Hamlet, Kenneth Branagh" becomes "Hamlet".
+// Names which are not set apart are left alone, as they cannot be told from the title.
+// A comma-separated name is only dropped after a single word title,
+// as titles such as "Crouching Tiger, Hidden Dragon" are made of similar parts.
+func dropTrailingName(query string) string {
+	trimmed := strings.TrimSpace(query)
+	if strings.HasSuffix(trimmed, ")") {
+		if i := strings.LastIndex(trimmed, "("); i > 0 && isName(trimmed[i+1:len(trimmed)-1]) {
+			return strings.TrimSpace(trimmed[:i])
+		}
+		return query
+	}
+
+	title, name, ok := strings.Cut(trimmed, ",")
+	if ok && len(strings.Fields(title)) == 1 && isName(name) {
+		return strings.TrimSpace(title)
+	}
+
+	return query
+}
+
+// isName reports whether s looks like the name of a person: two or three capitalized words.
+func isName(s string) bool {
+	words := strings.Fields(s)
+	if len(words) < 2 || len(words) > 3 {
+		return false
+	}
+	for _, w := range words {
+		if !nameWordRegex.MatchString(w) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// transliterate removes the diacritics of query, e.g. "Amélie" becomes "Amelie".
+func transliterate(query string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, query)
+	if err != nil {
+		return query
+	}
+
+	return result
+}
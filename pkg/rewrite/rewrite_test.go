@@ -0,0 +1,165 @@
+package rewrite
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type testCase struct {
+	input    string
+	expected string
+}
+
+var testData = []struct {
+	name  string
+	apply func(string) string
+	cases []testCase
+}{
+	{
+		name:  "dropArticle",
+		apply: dropArticle,
+		cases: []testCase{
+			{input: "The Matrix", expected: "Matrix"},
+			{input: "the matrix", expected: "matrix"},
+			{input: "A Quiet Place", expected: "Quiet Place"},
+			{input: "Le Fabuleux Destin d'Amélie Poulain", expected: "Fabuleux Destin d'Amélie Poulain"},
+			{input: "L'Odyssée", expected: "Odyssée"},
+			{input: "L’Odyssée", expected: "Odyssée"},
+			{input: "Il Postino", expected: "Postino"},
+			{input: "İl Postino", expected: "İl Postino"},
+			{input: "The İstanbul Job", expected: "İstanbul Job"},
+			{input: "Das Boot", expected: "Boot"},
+			{input: "Theodore Rex", expected: "Theodore Rex"},
+			{input: "Alien", expected: "Alien"},
+			{input: "The", expected: "The"},
+		},
+	},
+	{
+		name:  "romanToArabic",
+		apply: romanToArabic,
+		cases: []testCase{
+			{input: "Rocky II", expected: "Rocky 2"},
+			{input: "Star Wars Episode IV", expected: "Star Wars Episode 4"},
+			{input: "Rocky ii", expected: "Rocky 2"},
+			{input: "I Robot", expected: "I Robot"},
+			{input: "Mix", expected: "Mix"},
+		},
+	},
+	{
+		name:  "arabicToRoman",
+		apply: arabicToRoman,
+		cases: []testCase{
+			{input: "Rocky 2", expected: "Rocky II"},
+			{input: "Ocean's 11", expected: "Ocean's XI"},
+			{input: "1917", expected: "1917"},
+			{input: "Apollo 13", expected: "Apollo XIII"},
+			{input: "9", expected: "IX"},
+			{input: "1 Day", expected: "1 Day"},
+		},
+	},
+	{
+		name:  "swapAmpersand",
+		apply: swapAmpersand,
+		cases: []testCase{
+			{input: "Fast & Furious", expected: "Fast and Furious"},
+			{input: "Fast and Furious", expected: "Fast & Furious"},
+			{input: "Pride AND Prejudice", expected: "Pride & Prejudice"},
+			{input: "Andor", expected: "Andor"},
+			{input: "Alien", expected: "Alien"},
+		},
+	},
+	{
+		name:  "dropSubtitle",
+		apply: dropSubtitle,
+		cases: []testCase{
+			{input: "Alien: Covenant", expected: "Alien"},
+			{input: "Mission Impossible - Fallout", expected: "Mission Impossible"},
+			{input: "Spider-Man", expected: "Spider-Man"},
+			{input: "Alien", expected: "Alien"},
+		},
+	},
+	{
+		name:  "dropTrailingName",
+		apply: dropTrailingName,
+		cases: []testCase{
+			{input: "Hamlet (Kenneth Branagh)", expected: "Hamlet"},
+			{input: "Hamlet, Kenneth Branagh", expected: "Hamlet"},
+			{input: "Hamlet (Jean-Pierre O'Brien)", expected: "Hamlet"},
+			{input: "Hamlet (J. Smith)", expected: "Hamlet"},
+			{input: "Hamlet Kenneth Branagh", expected: "Hamlet Kenneth Branagh"},
+			{input: "The Dark Knight Rises", expected: "The Dark Knight Rises"},
+			{input: "(500) Days of Summer", expected: "(500) Days of Summer"},
+			{input: "Crouching Tiger, Hidden Dragon", expected: "Crouching Tiger, Hidden Dragon"},
+			{input: "Sex, Lies, and Videotape", expected: "Sex, Lies, and Videotape"},
+			{input: "Paris, Texas", expected: "Paris, Texas"},
+			{input: "Star Wars (Episode IV)", expected: "Star Wars (Episode IV)"},
+			{input: "Hamlet (1996)", expected: "Hamlet (1996)"},
+		},
+	},
+	{
+		name:  "transliterate",
+		apply: transliterate,
+		cases: []testCase{
+			{input: "Amélie", expected: "Amelie"},
+			{input: "Das Boot", expected: "Das Boot"},
+			{input: "Y tu mamá también", expected: "Y tu mama tambien"},
+			{input: "Crème Brûlée", expected: "Creme Brulee"},
+		},
+	},
+}
+
+func TestRules(t *testing.T) {
+	for _, data := range testData {
+		for i, tc := range data.cases {
+			t.Run(fmt.Sprintf("%s_%03d", data.name, i), func(t *testing.T) {
+				result := data.apply(tc.input)
+				if result != tc.expected {
+					t.Errorf("For input '%s', expected '%s' but got '%s'", tc.input, tc.expected, result)
+				}
+			})
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []Query
+	}{
+		{
+			input: "The Matrix II",
+			expected: []Query{
+				{Rule: "drop-article", Query: "Matrix II"},
+				{Rule: "roman-to-arabic", Query: "The Matrix 2"},
+			},
+		},
+		{
+			input: "Alien: Covenant",
+			expected: []Query{
+				{Rule: "drop-subtitle", Query: "Alien"},
+			},
+		},
+		{
+			// Rewrites equal to the query or to a previous rewrite are skipped.
+			input: "Amélie & Co",
+			expected: []Query{
+				{Rule: "ampersand", Query: "Amélie and Co"},
+				{Rule: "transliterate", Query: "Amelie & Co"},
+			},
+		},
+		{
+			input:    "Alien",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result := Expand(tc.input)
+			if !slices.Equal(result, tc.expected) {
+				t.Errorf("For input '%s', expected %v but got %v", tc.input, tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Package score provides the scoring of search results against the searched media.
+// Titles are compared with a selectable string similarity metric,
+// and the title, year and popularity components are combined with configurable weights.
+package score
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/adrg/strutil"
+	"github.com/adrg/strutil/metrics"
+)
+
+// Metric names.
+const (
+	MetricJaroWinkler        = "jaro-winkler"
+	MetricLevenshtein        = "levenshtein"
+	MetricJaccard            = "jaccard"
+	MetricOverlap            = "overlap"
+	MetricSmithWatermanGotoh = "smith-waterman-gotoh"
+)
+
+// Metrics lists the names of the available similarity metrics.
+var Metrics = []string{MetricJaroWinkler, MetricLevenshtein, MetricJaccard, MetricOverlap, MetricSmithWatermanGotoh}
+
+// Weights of the score components.
+type Weights struct {
+	// Title weighs the title mismatch, it is much larger than the others so better titles almost always win.
+	Title float64
+	// Year weighs the distance to the searched year, along with the rank of the result.
+	Year float64
+	// Popularity weighs the lack of popularity.
+	Popularity float64
+}
+
+// DefaultWeights are the weights used unless configured otherwise.
+var DefaultWeights = Weights{Title: 1000, Year: 1, Popularity: 1}
+
+// Options configures a scorer.
+type Options struct {
+	// Metric is the name of the similarity metric comparing titles, one of Metrics.
+	Metric  string
+	Weights Weights
+	// YearTolerance is the number of years a result may differ from the searched year without lowering the confidence.
+	YearTolerance int
+}
+
+// Match describes a search result to score against the searched media.
+type Match struct {
+	// Query and Year describe the searched media, Year is 0 when unknown.
+	Query string
+	Year  int
+
+	// Name, ResultYear and Popularity (0-100) describe the result, ResultYear is 0 when unknown.
+	Name       string
+	ResultYear int
+	Popularity int
+	// Index is the rank of the result in the search results.
+	Index int
+}
+
+// Breakdown is a score along with its weighted components, lower is better.
+type Breakdown struct {
+	// TitleSimilarity is the similarity of the titles, from 0 to 1, higher is better.
+	TitleSimilarity float64 `json:"title_similarity"`
+	Title           float64 `json:"title"`
+	Year            float64 `json:"year"`
+	Popularity      float64 `json:"popularity"`
+	Total           float64 `json:"total"`
+	// Confidence is the confidence that the result is the searched media, from 0 to 1, higher is better.
+	// Unlike the total, it can be compared across searches, but not across metrics:
+	// it is the raw title similarity on the scale of the metric, lowered by the year distance.
+	// The same titles get different similarities from different metrics, e.g. jaro-winkler rates them higher than levenshtein.
+	Confidence float64 `json:"confidence"`
+}
+
+// Scorer scores search results against the searched media.
+type Scorer interface {
+	// Similarity returns the similarity of strings a and b, from 0 to 1, higher is better.
+	Similarity(a, b string) float64
+	// Score returns the score of m, lower is better.
+	Score(m Match) Breakdown
+}
+
+// Default is the scorer used unless configured otherwise.
+var Default Scorer = &weighted{
+	similarity: jaroWinkler,
+	weights:    DefaultWeights,
+}
+
+type weighted struct {
+	similarity    func(a, b string) float64
+	weights       Weights
+	yearTolerance int
+}
+
+// New returns a scorer with the given options.
+func New(o Options) (Scorer, error) {
+	var similarity func(a, b string) float64
+	switch o.Metric {
+	case MetricJaroWinkler, "":
+		similarity = jaroWinkler
+	case MetricLevenshtein:
+		similarity = levenshtein
+	case MetricJaccard:
+		similarity = jaccard
+	case MetricOverlap:
+		similarity = overlap
+	case MetricSmithWatermanGotoh:
+		similarity = smithWatermanGotoh
+	default:
+		return nil, fmt.Errorf("unknown metric: %s (available: %s)", o.Metric, strings.Join(Metrics, ", "))
+	}
+
+	if slices.ContainsFunc([]float64{o.Weights.Title, o.Weights.Year, o.Weights.Popularity}, func(w float64) bool { return w < 0 }) {
+		return nil, fmt.Errorf("weights must not be negative: %+v", o.Weights)
+	}
+	if o.YearTolerance < 0 {
+		return nil, fmt.Errorf("year tolerance must not be negative: %d", o.YearTolerance)
+	}
+
+	return &weighted{
+		similarity:    similarity,
+		weights:       o.Weights,
+		yearTolerance: o.YearTolerance,
+	}, nil
+}
+
+// Similarity compares a and b case insensitively, ignoring surrounding spaces.
+func (s *weighted) Similarity(a, b string) float64 {
+	return s.similarity(normalize(a), normalize(b))
+}
+
+// Score returns the weighted sum of the title mismatch, the year distance and the lack of popularity.
+// Without a year, the rank of the result is used as a small tiebreaker instead.
+func (s *weighted) Score(m Match) Breakdown {
+	var yearScore float64
+	if m.Year > 0 {
+		yearScore = closestYearScore(m.Year, m.ResultYear, m.Index)
+	} else {
+		yearScore = float64(m.Index)
+	}
+
+	b := Breakdown{
+		TitleSimilarity: s.Similarity(m.Query, m.Name),
+		Year:            yearScore * s.weights.Year,
+		// Popularity ranges from 0 to 100, it is inverted so higher popularity gives a lower score.
+		Popularity: (100.0 - float64(m.Popularity)) * s.weights.Popularity,
+	}
+	b.Title = (1.0 - b.TitleSimilarity) * s.weights.Title
+	b.Total = b.Title + b.Year + b.Popularity
+	b.Confidence = b.TitleSimilarity * yearConfidence(m.Year, m.ResultYear, s.yearTolerance)
+
+	return b
+}
+
+// yearConfidence returns 1 when the years are within tolerance or either is unknown,
+// it halves for the first year beyond tolerance, and keeps decreasing with every other year.
+func yearConfidence(targetYear int, actualYear int, tolerance int) float64 {
+	if targetYear <= 0 || actualYear <= 0 {
+		return 1
+	}
+
+	excess := abs(targetYear-actualYear) - tolerance
+	if excess <= 0 {
+		return 1
+	}
+
+	return 1 / float64(1+excess)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// closestYearScore grows with the distance between the years, and exponentially with the rank of the result.
+func closestYearScore(targetYear int, actualYear int, index int) float64 {
+	return (math.Abs(float64(targetYear-actualYear)) + 1) * (math.Exp(float64(index + 2)))
+}
+
+// normalize normalizes a string for better matching by converting to lowercase and trimming whitespace
+func normalize(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.ToLower(s)
+	return s
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity between two strings as a float64 (0-1 range).
+// This is ideal for comparing titles and names.
+func jaroWinkler(a, b string) float64 {
+	return strutil.Similarity(a, b, metrics.NewJaroWinkler())
+}
+
+// levenshtein returns the Levenshtein similarity between two strings, the edit distance relative to their length.
+func levenshtein(a, b string) float64 {
+	return strutil.Similarity(a, b, metrics.NewLevenshtein())
+}
+
+// jaccard returns the Jaccard similarity between the bigrams of two strings.
+func jaccard(a, b string) float64 {
+	return strutil.Similarity(a, b, metrics.NewJaccard())
+}
+
+// overlap returns the overlap coefficient between the bigrams of two strings.
+func overlap(a, b string) float64 {
+	return strutil.Similarity(a, b, metrics.NewOverlapCoefficient())
+}
+
+// smithWatermanGotoh returns the Smith-Waterman-Gotoh similarity between two strings, which favours local alignments.
+func smithWatermanGotoh(a, b string) float64 {
+	return strutil.Similarity(a, b, metrics.NewSmithWatermanGotoh())
+}
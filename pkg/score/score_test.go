@@ -0,0 +1,148 @@
+package score
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/adrg/strutil"
+	"github.com/adrg/strutil/metrics"
+)
+
+var matches = []Match{
+	{Query: "The Matrix", Year: 1999, Name: "The Matrix", ResultYear: 1999, Popularity: 80, Index: 0},
+	{Query: "The Matrix", Year: 1999, Name: "The Matrix Reloaded", ResultYear: 2003, Popularity: 70, Index: 1},
+	{Query: "the matrix ", Name: "The Matrix", ResultYear: 1999, Popularity: 80, Index: 2},
+	{Query: "Amelie", Year: 2001, Name: "Amélie", Popularity: 40, Index: 0},
+	{Query: "Alien", Name: "Aliens", ResultYear: 1986, Popularity: 0, Index: 3},
+}
+
+// legacyScore is the score of m as computed by BestMatch before scoring was configurable.
+func legacyScore(m Match) float64 {
+	var yearScore float64
+	if m.Year > 0 {
+		yearScore = (math.Abs(float64(m.Year-m.ResultYear)) + 1) * (math.Exp(float64(m.Index + 2)))
+	} else {
+		yearScore = float64(m.Index)
+	}
+
+	titleScore := strutil.Similarity(normalize(m.Query), normalize(m.Name), metrics.NewJaroWinkler())
+	popularityScore := 100.0 - float64(m.Popularity)
+
+	return (1.0-titleScore)*1000.0 + yearScore + popularityScore
+}
+
+func TestDefaultMatchesLegacyScore(t *testing.T) {
+	configured, err := New(Options{Metric: MetricJaroWinkler, Weights: DefaultWeights})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, m := range matches {
+		expected := legacyScore(m)
+		for name, s := range map[string]Scorer{"Default": Default, "New": configured} {
+			t.Run(fmt.Sprintf("%s_%03d", name, i), func(t *testing.T) {
+				result := s.Score(m).Total
+				if math.Abs(result-expected) > 1e-9 {
+					t.Errorf("For input '%s' and '%s', expected '%f' but got '%f'", m.Query, m.Name, expected, result)
+				}
+			})
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	expected := map[string]func(a, b string) float64{
+		MetricJaroWinkler:        jaroWinkler,
+		"":                       jaroWinkler,
+		MetricLevenshtein:        levenshtein,
+		MetricJaccard:            jaccard,
+		MetricOverlap:            overlap,
+		MetricSmithWatermanGotoh: smithWatermanGotoh,
+	}
+
+	pairs := [][2]string{{"The Matrix", "the matrix"}, {"The Matrix", "The Matrix Reloaded"}, {"Alien", "Aliens"}, {"Alien", "Heat"}}
+	for metric, similarity := range expected {
+		t.Run(fmt.Sprintf("metric_%s", metric), func(t *testing.T) {
+			s, err := New(Options{Metric: metric, Weights: DefaultWeights})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range pairs {
+				result := s.Similarity(p[0], p[1])
+				if want := similarity(normalize(p[0]), normalize(p[1])); result != want {
+					t.Errorf("For input '%s' and '%s', expected '%f' but got '%f'", p[0], p[1], want, result)
+				}
+				if result < 0 || result > 1 {
+					t.Errorf("For input '%s' and '%s', expected a similarity between 0 and 1 but got '%f'", p[0], p[1], result)
+				}
+			}
+			if result := s.Similarity(" The Matrix", "the matrix"); result != 1 {
+				t.Errorf("expected titles differing by case and spaces to be identical, got '%f'", result)
+			}
+		})
+	}
+
+	// Metrics disagree on the same titles, confidences are on the scale of their metric.
+	jw, _ := New(Options{Metric: MetricJaroWinkler})
+	lev, _ := New(Options{Metric: MetricLevenshtein})
+	if a, b := jw.Similarity("Alien", "Heat"), lev.Similarity("Alien", "Heat"); a == b {
+		t.Errorf("expected metrics to differ, got '%f' for both", a)
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options Options
+		valid   bool
+	}{
+		{name: "default", options: Options{}, valid: true},
+		{name: "configured", options: Options{Metric: MetricJaccard, Weights: Weights{Title: 500, Year: 2}, YearTolerance: 1}, valid: true},
+		{name: "unknown metric", options: Options{Metric: "hamming"}, valid: false},
+		{name: "negative title weight", options: Options{Weights: Weights{Title: -1}}, valid: false},
+		{name: "negative year weight", options: Options{Weights: Weights{Year: -1}}, valid: false},
+		{name: "negative popularity weight", options: Options{Weights: Weights{Popularity: -1}}, valid: false},
+		{name: "negative year tolerance", options: Options{YearTolerance: -1}, valid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(tc.options)
+			if tc.valid && (err != nil || s == nil) {
+				t.Errorf("expected options %+v to be valid, got %v", tc.options, err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("expected options %+v to be rejected", tc.options)
+			}
+		})
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	testCases := []struct {
+		match     Match
+		tolerance int
+		expected  float64
+	}{
+		{match: Match{Query: "The Matrix", Year: 1999, Name: "The Matrix", ResultYear: 1999}, expected: 1},
+		{match: Match{Query: "The Matrix", Name: "The Matrix", ResultYear: 1999}, expected: 1},
+		{match: Match{Query: "The Matrix", Year: 1999, Name: "The Matrix", ResultYear: 2000}, expected: 0.5},
+		{match: Match{Query: "The Matrix", Year: 1999, Name: "The Matrix", ResultYear: 2000}, tolerance: 1, expected: 1},
+		{match: Match{Query: "The Matrix", Year: 1999, Name: "The Matrix", ResultYear: 2002}, tolerance: 1, expected: 1.0 / 3},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("confidence_%03d", i), func(t *testing.T) {
+			s, err := New(Options{YearTolerance: tc.tolerance})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := s.Score(tc.match).Confidence
+			if math.Abs(result-tc.expected) > 1e-9 {
+				t.Errorf("For input %+v, expected '%f' but got '%f'", tc.match, tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+// Package server implements a REST API to scan paths into rename plans, review them and execute them.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/renamer"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+// ErrNotApproved is set on entries which were not approved when their plan is executed.
+var ErrNotApproved = fmt.Errorf("%w: not approved", source.ErrExcludedPath)
+
+// planner is the renamer of a plan, it is kept to review the plan and execute it.
+type planner interface {
+	Execute(context.Context, *renamer.Plan) error
+	Rematch(ctx context.Context, plan *renamer.Plan, index, candidate int) error
+	Override(plan *renamer.Plan, index int, destination string) error
+}
+
+// Server serves the REST API.
+// Providers, along with their HTTP cache, are shared by all requests.
+type Server struct {
+	providers     []provider.Interface
+	renameOptions renamer.Options
+	sourceOptions source.Options
+
+	// mu guards plans and serializes their review, as renamers are not safe for concurrent use.
+	// Scans only use their own renamer and run without it, providers are safe for concurrent use.
+	// Executions run without it on a copy of their plan, while their session refuses other changes.
+	mu     sync.Mutex
+	plans  map[string]*session
+	nextID int
+}
+
+// session is a plan under review.
+type session struct {
+	ID        string        `json:"id"`
+	Path      string        `json:"path"`
+	CreatedAt time.Time     `json:"created_at"`
+	Executed  bool          `json:"executed"`
+	Executing bool          `json:"executing"`
+	Approved  []int         `json:"approved"`
+	Plan      *renamer.Plan `json:"plan"`
+
+	renamer planner
+}
+
+// entryUpdate is the body of an entry update, every field is optional.
+type entryUpdate struct {
+	// Approved approves or rejects the entry and its companions
+	Approved *bool `json:"approved"`
+	// Candidate selects another candidate of the entry
+	Candidate *int `json:"candidate"`
+	// Destination sets the destination of the entry
+	Destination *string `json:"destination"`
+}
+
+func New(providers []provider.Interface, renameOptions renamer.Options, sourceOptions source.Options) *Server {
+	return &Server{
+		providers:     providers,
+		renameOptions: renameOptions,
+		sourceOptions: sourceOptions,
+		plans:         make(map[string]*session),
+	}
+}
+
+// Handler returns the HTTP handler of the API.
+//
+//	POST   /api/v1/plans                         scan a path, body: {"path": "/path/to/dir"}
+//	GET    /api/v1/plans                         list plans
+//	GET    /api/v1/plans/{id}                    get a plan
+//	DELETE /api/v1/plans/{id}                    delete a plan
+//	PATCH  /api/v1/plans/{id}/entries/{index}    approve or override an entry, body: {"approved": true, "candidate": 1, "destination": "/path"}
+//	POST   /api/v1/plans/{id}/execute            execute the approved entries of a plan
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/plans", s.createPlan)
+	mux.HandleFunc("GET /api/v1/plans", s.listPlans)
+	mux.HandleFunc("GET /api/v1/plans/{id}", s.getPlan)
+	mux.HandleFunc("DELETE /api/v1/plans/{id}", s.deletePlan)
+	mux.HandleFunc("PATCH /api/v1/plans/{id}/entries/{index}", s.updateEntry)
+	mux.HandleFunc("POST /api/v1/plans/{id}/execute", s.executePlan)
+
+	return logRequests(mux)
+}
+
+func (s *Server) createPlan(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Path string `json:"path"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to decode body: %w", err))
+		return
+	}
+	if body.Path == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("path is required"))
+		return
+	}
+
+	path, err := filepath.Abs(body.Path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	_, err = os.Lstat(path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rn, err := renamer.New([]string{path}, s.providers, s.renameOptions)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	plan, err := rn.Plan(r.Context(), s.sourceOptions)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	sess := &session{
+		ID:        strconv.Itoa(s.nextID),
+		Path:      path,
+		CreatedAt: plan.CreatedAt,
+		Approved:  []int{},
+		Plan:      plan,
+		renamer:   rn,
+	}
+	s.plans[sess.ID] = sess
+
+	writeJSON(w, http.StatusCreated, sess)
+}
+
+func (s *Server) listPlans(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := make([]*session, 0, len(s.plans))
+	for _, sess := range s.plans {
+		sessions = append(sessions, sess)
+	}
+	slices.SortFunc(sessions, func(a, b *session) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (s *Server) getPlan(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.plans[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("plan not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) deletePlan(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.plans[r.PathValue("id")]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("plan not found"))
+		return
+	}
+	delete(s.plans, r.PathValue("id"))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) updateEntry(w http.ResponseWriter, r *http.Request) {
+	var update entryUpdate
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to decode body: %w", err))
+		return
+	}
+	if update.Candidate != nil && update.Destination != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("candidate and destination are mutually exclusive"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.plans[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("plan not found"))
+		return
+	}
+	if sess.Executed {
+		writeError(w, http.StatusConflict, fmt.Errorf("plan already executed"))
+		return
+	}
+	if sess.Executing {
+		writeError(w, http.StatusConflict, fmt.Errorf("plan is being executed"))
+		return
+	}
+
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 0 || index >= len(sess.Plan.Entries) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("entry not found"))
+		return
+	}
+
+	switch {
+	case update.Candidate != nil:
+		err = sess.renamer.Rematch(r.Context(), sess.Plan, index, *update.Candidate)
+	case update.Destination != nil:
+		err = sess.renamer.Override(sess.Plan, index, *update.Destination)
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if update.Approved != nil {
+		// Companions follow their media.
+		indexes := append([]int{index}, sess.Plan.CompanionsOf(index)...)
+		for _, i := range indexes {
+			sess.Approved = slices.DeleteFunc(sess.Approved, func(a int) bool { return a == i })
+			if *update.Approved {
+				sess.Approved = append(sess.Approved, i)
+			}
+		}
+		slices.Sort(sess.Approved)
+	}
+
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) executePlan(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	sess, ok := s.plans[r.PathValue("id")]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, fmt.Errorf("plan not found"))
+		return
+	}
+	if sess.Executed {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Errorf("plan already executed"))
+		return
+	}
+	if sess.Executing {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Errorf("plan is being executed"))
+		return
+	}
+	if len(sess.Approved) == 0 {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Errorf("no approved entry"))
+		return
+	}
+
+	// Only approved entries are executed, others are reported as excluded.
+	plan := *sess.Plan
+	plan.Entries = slices.Clone(sess.Plan.Entries)
+	for i := range plan.Entries {
+		if plan.Entries[i].Error == nil && !slices.Contains(sess.Approved, i) {
+			plan.Entries[i].Error = ErrNotApproved
+		}
+	}
+	plan.Directories = slices.DeleteFunc(slices.Clone(sess.Plan.Directories), func(dir string) bool {
+		return !slices.ContainsFunc(plan.Entries, func(e renamer.Entry) bool {
+			return e.Error == nil && filepath.Dir(e.Destination) == dir
+		})
+	})
+	sess.Executing = true
+	s.mu.Unlock()
+
+	// Files are written without holding the lock, other plans can be reviewed meanwhile.
+	err := sess.renamer.Execute(r.Context(), &plan)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess.Executing = false
+	sess.Plan = &plan
+	if r.Context().Err() != nil {
+		// The client went away, the plan was partially executed and cannot be executed again.
+		sess.Executed = true
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	sess.Executed = true
+
+	writeJSON(w, http.StatusOK, sess)
+}
+
+// writeJSON writes v as the JSON response body with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Err(err).Msg("failed to write response")
+	}
+}
+
+// writeError writes err as the JSON response body with the given status code.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// logRequests logs every request served by next.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Debug().Str("method", r.Method).Str("path", r.URL.Path).Dur("duration", time.Since(start)).Msg("served request")
+	})
+}
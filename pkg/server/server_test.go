@@ -0,0 +1,366 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+	"github.com/TheoBrigitte/evansky/pkg/renamer"
+	"github.com/TheoBrigitte/evansky/pkg/renamer/format"
+	"github.com/TheoBrigitte/evansky/pkg/source"
+)
+
+// newTestServer returns the handler of a server renaming with moves into the output directory of root,
+// along with the source directory of root, holding a movie and an episode with a misspelled show name.
+func newTestServer(t *testing.T, minConfidence float64) (http.Handler, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	err := os.MkdirAll(src, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"The Matrix 1999.mkv", "Dakr S01E02.mkv"} {
+		err = os.WriteFile(filepath.Join(src, name), []byte(name), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &providertest.Provider{
+		Movies: []providertest.Movie{
+			{ID: 1, Name: "The Matrix", Year: 1999},
+			{ID: 2, Name: "The Matrix Reloaded", Year: 2003},
+		},
+		Shows: []providertest.Show{
+			{ID: 10, Name: "Dark", Year: 2017, Seasons: []int{0, 3}},
+		},
+	}
+	renameOptions := renamer.Options{
+		Formatter:  format.NewPlexFormatter(),
+		Output:     filepath.Join(root, "out"),
+		Report:     io.Discard,
+		RenameMode: "move",
+		Write:      true,
+	}
+	sourceOptions := source.Options{
+		MediaExts:     []string{"mkv"},
+		MinConfidence: minConfidence,
+		// The source directory itself is not a media.
+		StripComponents: 1,
+	}
+
+	return New([]provider.Interface{p}, renameOptions, sourceOptions).Handler(), src
+}
+
+// do serves a request with body encoded as JSON, and decodes the response body into out when not nil.
+func do(t *testing.T, h http.Handler, method, path string, body, out any) int {
+	t.Helper()
+
+	var b bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&b).Encode(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), method, path, &b))
+
+	if out != nil {
+		err := json.NewDecoder(w.Body).Decode(out)
+		if err != nil {
+			t.Fatalf("failed to decode response of %s %s: %v", method, path, err)
+		}
+	}
+
+	return w.Code
+}
+
+// entryOf returns the index of the entry of sess whose source is named name.
+func entryOf(t *testing.T, sess session, name string) int {
+	t.Helper()
+
+	i := slices.IndexFunc(sess.Plan.Entries, func(e renamer.Entry) bool { return filepath.Base(e.Source) == name })
+	if i < 0 {
+		t.Fatalf("entry %q not found", name)
+	}
+
+	return i
+}
+
+func TestPlans(t *testing.T) {
+	h, src := newTestServer(t, 0)
+
+	testCases := []struct {
+		name   string
+		body   any
+		status int
+	}{
+		{name: "no path", body: map[string]string{}, status: http.StatusBadRequest},
+		{name: "missing path", body: map[string]string{"path": filepath.Join(src, "missing")}, status: http.StatusBadRequest},
+		{name: "invalid body", body: "path", status: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status := do(t, h, http.MethodPost, "/api/v1/plans", tc.body, nil)
+			if status != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, status)
+			}
+		})
+	}
+
+	var created session
+	status := do(t, h, http.MethodPost, "/api/v1/plans", map[string]string{"path": src}, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, status)
+	}
+	if created.ID == "" || len(created.Plan.Entries) != 2 {
+		t.Fatalf("expected a plan with 2 entries, got %+v", created)
+	}
+
+	var sessions []session
+	status = do(t, h, http.MethodGet, "/api/v1/plans", nil, &sessions)
+	if status != http.StatusOK || len(sessions) != 1 || sessions[0].ID != created.ID {
+		t.Fatalf("expected plan %s to be listed, got %d %+v", created.ID, status, sessions)
+	}
+
+	var got session
+	status = do(t, h, http.MethodGet, "/api/v1/plans/"+created.ID, nil, &got)
+	if status != http.StatusOK || got.ID != created.ID {
+		t.Fatalf("expected plan %s, got %d %+v", created.ID, status, got)
+	}
+
+	status = do(t, h, http.MethodDelete, "/api/v1/plans/"+created.ID, nil, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, status)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		status = do(t, h, method, "/api/v1/plans/"+created.ID, nil, nil)
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d for %s of a deleted plan, got %d", http.StatusNotFound, method, status)
+		}
+	}
+}
+
+func TestExecuteApproved(t *testing.T) {
+	h, src := newTestServer(t, 0)
+
+	var sess session
+	do(t, h, http.MethodPost, "/api/v1/plans", map[string]string{"path": src}, &sess)
+	base := "/api/v1/plans/" + sess.ID
+
+	status := do(t, h, http.MethodPost, base+"/execute", nil, nil)
+	if status != http.StatusConflict {
+		t.Fatalf("expected status %d without approved entry, got %d", http.StatusConflict, status)
+	}
+
+	movie := entryOf(t, sess, "The Matrix 1999.mkv")
+	status = do(t, h, http.MethodPatch, base+"/entries/"+strconv.Itoa(movie), map[string]bool{"approved": true}, &sess)
+	if status != http.StatusOK || !slices.Equal(sess.Approved, []int{movie}) {
+		t.Fatalf("expected entry %d to be approved, got %d %v", movie, status, sess.Approved)
+	}
+	status = do(t, h, http.MethodPatch, base+"/entries/9", map[string]bool{"approved": true}, nil)
+	if status != http.StatusNotFound {
+		t.Errorf("expected status %d for a missing entry, got %d", http.StatusNotFound, status)
+	}
+
+	status = do(t, h, http.MethodPost, base+"/execute", nil, &sess)
+	if status != http.StatusOK || !sess.Executed {
+		t.Fatalf("expected plan to be executed, got %d", status)
+	}
+
+	destination := sess.Plan.Entries[movie].Destination
+	if !strings.HasSuffix(destination, filepath.Join("The Matrix (1999) {test-1}", "The Matrix (1999).mkv")) {
+		t.Errorf("unexpected destination %q", destination)
+	}
+	if _, err := os.Lstat(destination); err != nil {
+		t.Errorf("expected approved entry to be moved: %v", err)
+	}
+	// The plan is decoded from JSON, errors are only known by their message.
+	episode := entryOf(t, sess, "Dakr S01E02.mkv")
+	if e := sess.Plan.Entries[episode]; e.Error == nil || e.Error.Error() != ErrNotApproved.Error() {
+		t.Errorf("expected entry which was not approved to be excluded, got %v", e.Error)
+	}
+	if _, err := os.Lstat(filepath.Join(src, "Dakr S01E02.mkv")); err != nil {
+		t.Errorf("expected entry which was not approved to stay in place: %v", err)
+	}
+
+	status = do(t, h, http.MethodPost, base+"/execute", nil, nil)
+	if status != http.StatusConflict {
+		t.Errorf("expected status %d for an executed plan, got %d", http.StatusConflict, status)
+	}
+	status = do(t, h, http.MethodPatch, base+"/entries/"+strconv.Itoa(episode), map[string]bool{"approved": true}, nil)
+	if status != http.StatusConflict {
+		t.Errorf("expected status %d when updating an executed plan, got %d", http.StatusConflict, status)
+	}
+}
+
+func TestUpdateEntry(t *testing.T) {
+	h, src := newTestServer(t, 0)
+
+	var sess session
+	do(t, h, http.MethodPost, "/api/v1/plans", map[string]string{"path": src}, &sess)
+	base := "/api/v1/plans/" + sess.ID
+	movie := entryOf(t, sess, "The Matrix 1999.mkv")
+	episode := entryOf(t, sess, "Dakr S01E02.mkv")
+
+	candidate := slices.IndexFunc(sess.Plan.Entries[movie].Candidates, func(c provider.Candidate) bool { return c.Name == "The Matrix Reloaded" })
+	status := do(t, h, http.MethodPatch, base+"/entries/"+strconv.Itoa(movie), map[string]int{"candidate": candidate}, &sess)
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if d := sess.Plan.Entries[movie].Destination; !strings.HasSuffix(d, filepath.Join("The Matrix Reloaded (2003) {test-2}", "The Matrix Reloaded (2003).mkv")) {
+		t.Errorf("expected entry to be rematched, got destination %q", d)
+	}
+
+	destination := filepath.Join(src, "Dark - s01e02.mkv")
+	status = do(t, h, http.MethodPatch, base+"/entries/"+strconv.Itoa(episode), map[string]string{"destination": destination}, &sess)
+	if status != http.StatusOK || sess.Plan.Entries[episode].Destination != destination {
+		t.Errorf("expected entry destination to be overridden, got %d %q", status, sess.Plan.Entries[episode].Destination)
+	}
+
+	testCases := []struct {
+		name   string
+		body   any
+		status int
+	}{
+		{name: "missing candidate", body: map[string]int{"candidate": 9}, status: http.StatusUnprocessableEntity},
+		{name: "candidate and destination", body: map[string]any{"candidate": 0, "destination": destination}, status: http.StatusBadRequest},
+		{name: "same destination", body: map[string]string{"destination": sess.Plan.Entries[movie].Source}, status: http.StatusUnprocessableEntity},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status := do(t, h, http.MethodPatch, base+"/entries/"+strconv.Itoa(movie), tc.body, nil)
+			if status != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, status)
+			}
+		})
+	}
+}
+
+func TestRematchEpisode(t *testing.T) {
+	h, src := newTestServer(t, 0.99)
+
+	var sess session
+	do(t, h, http.MethodPost, "/api/v1/plans", map[string]string{"path": src}, &sess)
+	episode := entryOf(t, sess, "Dakr S01E02.mkv")
+
+	// The misspelled show needs review, its candidates are shows.
+	e := sess.Plan.Entries[episode]
+	if e.Error == nil || !strings.Contains(e.Error.Error(), source.ErrNeedsReview.Error()) || len(e.Candidates) == 0 {
+		t.Fatalf("expected episode to need review with candidates, got %v %v", e.Error, e.Candidates)
+	}
+
+	status := do(t, h, http.MethodPatch, "/api/v1/plans/"+sess.ID+"/entries/"+strconv.Itoa(episode), map[string]int{"candidate": 0}, &sess)
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	e = sess.Plan.Entries[episode]
+	if e.Error != nil || !strings.HasSuffix(e.Destination, filepath.Join("Dark (2017) {test-10}", "Season 01", "Dark (2017) - s01e02 - Episode 2.mkv")) {
+		t.Errorf("expected the episode of the chosen show, got %v %q", e.Error, e.Destination)
+	}
+}
+
+func TestRematchAcceptedEpisode(t *testing.T) {
+	h, src := newTestServer(t, 0)
+
+	var sess session
+	do(t, h, http.MethodPost, "/api/v1/plans", map[string]string{"path": src}, &sess)
+	episode := entryOf(t, sess, "Dakr S01E02.mkv")
+
+	// Without minimum confidence, the misspelled show is accepted, its candidates are shows.
+	e := sess.Plan.Entries[episode]
+	if e.Error != nil || len(e.Candidates) == 0 {
+		t.Fatalf("expected episode to be accepted with candidates, got %v %v", e.Error, e.Candidates)
+	}
+
+	status := do(t, h, http.MethodPatch, "/api/v1/plans/"+sess.ID+"/entries/"+strconv.Itoa(episode), map[string]int{"candidate": 0}, &sess)
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	e = sess.Plan.Entries[episode]
+	if e.Error != nil || !strings.HasSuffix(e.Destination, filepath.Join("Dark (2017) {test-10}", "Season 01", "Dark (2017) - s01e02 - Episode 2.mkv")) {
+		t.Errorf("expected the episode of the chosen show, got %v %q", e.Error, e.Destination)
+	}
+}
+
+// blockingPlanner holds executions back until release is closed, and signals started on execution.
+type blockingPlanner struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingPlanner) Execute(context.Context, *renamer.Plan) error {
+	close(p.started)
+	<-p.release
+
+	return nil
+}
+
+func (p *blockingPlanner) Rematch(context.Context, *renamer.Plan, int, int) error { return nil }
+
+func (p *blockingPlanner) Override(*renamer.Plan, int, string) error { return nil }
+
+func TestExecuteUnlocked(t *testing.T) {
+	s := New(nil, renamer.Options{}, source.Options{})
+	p := &blockingPlanner{started: make(chan struct{}), release: make(chan struct{})}
+	for _, id := range []string{"1", "2"} {
+		s.plans[id] = &session{
+			ID:       id,
+			Approved: []int{0},
+			Plan:     &renamer.Plan{Entries: []renamer.Entry{{Source: "a.mkv", Destination: "A.mkv"}}},
+			renamer:  p,
+		}
+	}
+	h := s.Handler()
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/api/v1/plans/1/execute", nil))
+		done <- w.Code
+	}()
+	<-p.started
+
+	// Plans can be read while one is executed, which refuses changes.
+	var sess session
+	status := do(t, h, http.MethodGet, "/api/v1/plans/1", nil, &sess)
+	if status != http.StatusOK || !sess.Executing {
+		t.Errorf("expected plan to be executing, got %d %+v", status, sess)
+	}
+	status = do(t, h, http.MethodPatch, "/api/v1/plans/1/entries/0", map[string]bool{"approved": false}, nil)
+	if status != http.StatusConflict {
+		t.Errorf("expected status %d when updating a plan being executed, got %d", http.StatusConflict, status)
+	}
+	status = do(t, h, http.MethodPost, "/api/v1/plans/1/execute", nil, nil)
+	if status != http.StatusConflict {
+		t.Errorf("expected status %d when executing a plan being executed, got %d", http.StatusConflict, status)
+	}
+	status = do(t, h, http.MethodPatch, "/api/v1/plans/2/entries/0", map[string]bool{"approved": false}, nil)
+	if status != http.StatusOK {
+		t.Errorf("expected other plan to be updated, got %d", status)
+	}
+
+	close(p.release)
+	if status := <-done; status != http.StatusOK {
+		t.Fatalf("expected plan to be executed, got %d", status)
+	}
+	do(t, h, http.MethodGet, "/api/v1/plans/1", nil, &sess)
+	if !sess.Executed || sess.Executing {
+		t.Errorf("expected plan to be executed, got %+v", sess)
+	}
+}
@@ -0,0 +1,119 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+)
+
+// extrasDirs lists the names of directories holding extras of a media, they are carried along with it.
+// https://jellyfin.org/docs/general/server/media/movies#extras
+var extrasDirs = []string{
+	"behind the scenes",
+	"deleted scenes",
+	"extras",
+	"featurettes",
+	"interviews",
+	"other",
+	"samples",
+	"scenes",
+	"shorts",
+	"trailers",
+}
+
+// isExtrasDir reports whether name is the name of an extras directory.
+func isExtrasDir(name string) bool {
+	return slices.Contains(extrasDirs, strings.ToLower(name))
+}
+
+// attachCompanions attaches the companions found directly in dir to the media they belong to.
+// A companion whose name starts with the name of a media file (without extension) belongs to this media,
+// e.g. "movie.nfo", "movie-poster.jpg" or "movie.chapters.xml" for "movie.mkv".
+// Other companions (poster.jpg, fanart.jpg, extras directories, etc.) belong to the media of dir,
+// provided there is a single one, parts of a multi-part movie counting as one.
+// Companions which cannot be attached to a media are reported as ignored.
+func attachCompanions(dir string, nodes []Node) []Node {
+	var medias []int
+	// unmatched counts the media of dir which could not be matched, they make the owner of other companions ambiguous.
+	var unmatched int
+	var companions []Node
+	result := make([]Node, 0, len(nodes))
+	for _, n := range nodes {
+		if filepath.Dir(n.Path) != dir {
+			result = append(result, n)
+			continue
+		}
+
+		if n.Type == NodeTypeCompanion && n.Error == nil {
+			companions = append(companions, n)
+			continue
+		}
+
+		if n.Type == NodeTypeMedia {
+			switch {
+			case n.Error == nil && n.Response != nil:
+				medias = append(medias, len(result))
+			case !errors.Is(n.Error, ErrExcludedPath):
+				unmatched++
+			}
+		}
+		result = append(result, n)
+	}
+
+	for _, c := range companions {
+		index := -1
+		for _, i := range medias {
+			base := strings.TrimSuffix(result[i].Entry.Name(), filepath.Ext(result[i].Entry.Name()))
+			if strings.HasPrefix(c.Entry.Name(), base+".") || strings.HasPrefix(c.Entry.Name(), base+"-") {
+				index = i
+				break
+			}
+		}
+		if index < 0 && len(medias) > 0 && unmatched == 0 && sameMedia(result, medias) {
+			index = medias[0]
+		}
+
+		if index < 0 {
+			c.Error = fmt.Errorf("%w: companion without media", ErrIgnoredPath)
+			if len(medias)+unmatched > 1 {
+				c.Error = fmt.Errorf("%w: companion of several media", ErrIgnoredPath)
+			}
+			result = append(result, c)
+			continue
+		}
+
+		result[index].Companions = append(result[index].Companions, c)
+	}
+
+	return result
+}
+
+// sameMedia reports whether the nodes at indexes are all matched to the same media.
+func sameMedia(nodes []Node, indexes []int) bool {
+	first := nodes[indexes[0]].Response
+	for _, i := range indexes[1:] {
+		r := nodes[i].Response
+		if r.GetProvider() != first.GetProvider() || r.GetID() != first.GetID() || provider.MediaTypeOf(r) != provider.MediaTypeOf(first) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CompanionName returns the name of the companion c once its media is renamed to mediaName.
+// Companions named after their media keep their suffix, e.g. "movie.chapters.xml" becomes "<mediaName>.chapters.xml".
+// Other companions keep their name.
+func CompanionName(c, media Node, mediaName string) string {
+	base := strings.TrimSuffix(media.Entry.Name(), filepath.Ext(media.Entry.Name()))
+	suffix, ok := strings.CutPrefix(c.Entry.Name(), base)
+	if !ok || (!strings.HasPrefix(suffix, ".") && !strings.HasPrefix(suffix, "-")) {
+		return c.Entry.Name()
+	}
+
+	return mediaName + suffix
+}
@@ -0,0 +1,115 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+)
+
+const companionDir = "/media"
+
+// companionNodes returns the nodes of files in companionDir, movie holds the ID of the movie each media file is matched to,
+// media files without a movie are not matched.
+func companionNodes(t *testing.T, files []string, movie map[string]int) []Node {
+	t.Helper()
+
+	fsys := fstest.MapFS{}
+	for _, f := range files {
+		fsys[f] = &fstest.MapFile{}
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make([]Node, 0, len(entries))
+	for _, e := range entries {
+		n := Node{Entry: e, Path: filepath.Join(companionDir, e.Name()), Type: NodeTypeCompanion}
+		if filepath.Ext(e.Name()) == ".mkv" {
+			n.Type = NodeTypeMedia
+			id, ok := movie[e.Name()]
+			if ok {
+				n.Response = providertest.NewMovieResponse(providertest.Movie{ID: id}, provider.Request{})
+			} else {
+				n.Error = errors.New("no result")
+			}
+		}
+		nodes = append(nodes, n)
+	}
+
+	return nodes
+}
+
+func TestAttachCompanions(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files []string
+		movie map[string]int
+		// expected maps each companion to the media it is attached to, or to "" when ignored.
+		expected map[string]string
+	}{
+		{
+			name:     "single media",
+			files:    []string{"movie.mkv", "movie.nfo", "poster.jpg"},
+			movie:    map[string]int{"movie.mkv": 1},
+			expected: map[string]string{"movie.nfo": "movie.mkv", "poster.jpg": "movie.mkv"},
+		},
+		{
+			name:     "several media",
+			files:    []string{"a.mkv", "a-poster.jpg", "b.mkv", "b.nfo", "fanart.jpg", "poster.jpg"},
+			movie:    map[string]int{"a.mkv": 1, "b.mkv": 2},
+			expected: map[string]string{"a-poster.jpg": "a.mkv", "b.nfo": "b.mkv", "fanart.jpg": "", "poster.jpg": ""},
+		},
+		{
+			name:     "parts of the same media",
+			files:    []string{"cd1.mkv", "cd2.mkv", "poster.jpg"},
+			movie:    map[string]int{"cd1.mkv": 1, "cd2.mkv": 1},
+			expected: map[string]string{"poster.jpg": "cd1.mkv"},
+		},
+		{
+			name:     "unmatched media",
+			files:    []string{"a.mkv", "b.mkv", "poster.jpg"},
+			movie:    map[string]int{"a.mkv": 1},
+			expected: map[string]string{"poster.jpg": ""},
+		},
+		{
+			name:     "no media",
+			files:    []string{"poster.jpg"},
+			expected: map[string]string{"poster.jpg": ""},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%s_%03d", strings.ReplaceAll(tc.name, " ", "_"), i), func(t *testing.T) {
+			nodes := attachCompanions(companionDir, companionNodes(t, tc.files, tc.movie))
+
+			result := map[string]string{}
+			for _, n := range nodes {
+				for _, c := range n.Companions {
+					result[c.Entry.Name()] = n.Entry.Name()
+				}
+				if n.Type == NodeTypeCompanion {
+					if !errors.Is(n.Error, ErrIgnoredPath) {
+						t.Errorf("expected companion %q left alone to be ignored, got %v", n.Entry.Name(), n.Error)
+					}
+					result[n.Entry.Name()] = ""
+				}
+			}
+			if len(result) != len(tc.expected) {
+				t.Errorf("For input %v, expected %v but got %v", tc.files, tc.expected, result)
+			}
+			for companion, media := range tc.expected {
+				if got, ok := result[companion]; !ok || got != media {
+					t.Errorf("For input %v, expected %q to belong to %q but got %q", tc.files, companion, media, got)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package source
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -9,6 +10,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 
@@ -19,7 +21,10 @@ import (
 
 var (
 	ErrExcludedPath = errors.New("excluded path")
-	ErrRetryable    = errors.New("retryable error")
+	ErrIgnoredPath  = errors.New("ignored path")
+	ErrRetryable    = provider.ErrRetryable
+	// ErrNeedsReview is returned along with a match whose confidence is below the minimum confidence.
+	ErrNeedsReview = errors.New("needs review")
 )
 
 // generic implements a generic source that can handle both movies and TV shows.
@@ -35,20 +40,24 @@ type generic struct {
 	titleRegex   *regexp.Regexp // Compiled regex for extracting title from file or directory name
 
 	providers []provider.Interface // List of metadata providers to query
+	workers   chan struct{}        // Tokens of the workers scanning entries besides the caller, bounded by the jobs option
 }
 
 func New(path string, providers []provider.Interface, o Options) *generic {
+	jobs := max(o.Jobs, 1)
+
 	return &generic{
 		path:      path,
 		providers: providers,
 		options:   o,
+		workers:   make(chan struct{}, jobs-1),
 	}
 }
 
 // scan scans the source path and returns a list of nodes.
 // It starts by getting information about the root path and then recursively
 // walks the directory tree to process each file and directory.
-func (g *generic) scan() ([]Node, error) {
+func (g *generic) scan(ctx context.Context) ([]Node, error) {
 	// Get initial file or directory info.
 	info, err := os.Lstat(g.path)
 	if err != nil {
@@ -106,7 +115,9 @@ func (g *generic) scan() ([]Node, error) {
 	}
 
 	// Start walking the directory tree.
-	return g.walk(g.path, dirInfo, 0, nil), nil
+	nodes := g.walk(ctx, g.path, dirInfo, 0, nil, nil)
+
+	return attachCompanions(filepath.Dir(g.path), nodes), nil
 }
 
 // walk recursively walks the directory tree and processes each file or directory.
@@ -115,12 +126,18 @@ func (g *generic) scan() ([]Node, error) {
 // 2. Detects the language based on directory contents
 // 3. Queries metadata providers to get accurate information
 // 4. Generates nodes for files or continues recursion for directories
-func (g *generic) walk(path string, entry fs.DirEntry, depth int, parentResp provider.Response) []Node {
+//
+// Child entries are walked concurrently, see walkChildren.
+// Once ctx is done, entries are no longer scanned and are returned with the context error.
+func (g *generic) walk(ctx context.Context, path string, entry fs.DirEntry, depth int, parentResp provider.Response, parentReq *provider.Request) []Node {
 	n := Node{
 		Entry: entry,
 		Path:  path,
 	}
 
+	// Extras of a TV show are its specials, they are looked up as season 0 instead of being carried along.
+	_, parentIsTV := parentResp.(provider.ResponseTV)
+
 	// Set node type based on file extension.
 	extension := strings.TrimPrefix(strings.ToLower(filepath.Ext(entry.Name())), ".")
 	switch {
@@ -128,6 +145,15 @@ func (g *generic) walk(path string, entry fs.DirEntry, depth int, parentResp pro
 		n.Type = NodeTypeMedia
 	case slices.Contains(g.options.SubtitleExts, extension):
 		n.Type = NodeTypeSubtitle
+	case slices.Contains(g.options.CompanionExts, extension):
+		n.Type = NodeTypeCompanion
+	case entry.IsDir() && parentResp != nil && !parentIsTV && isExtrasDir(entry.Name()):
+		n.Type = NodeTypeCompanion
+	}
+
+	if err := ctx.Err(); err != nil {
+		n.Error = fmt.Errorf("not scanned: %w", context.Cause(ctx))
+		return []Node{n}
 	}
 
 	// Skip entries that are explicitly excluded by glob patterns.
@@ -142,6 +168,12 @@ func (g *generic) walk(path string, entry fs.DirEntry, depth int, parentResp pro
 		return []Node{n}
 	}
 
+	if n.Type == NodeTypeCompanion {
+		// Companions are not looked up, they are attached to their media by the parent directory.
+		// They follow their media, include patterns do not apply.
+		return []Node{n}
+	}
+
 	// Skip entries that are explicitly excluded by glob patterns.
 	if len(g.includes) > 0 && !entry.IsDir() && !slices.Contains(g.includes, path) {
 		n.Error = fmt.Errorf("%w not included by glob", ErrExcludedPath)
@@ -155,7 +187,14 @@ func (g *generic) walk(path string, entry fs.DirEntry, depth int, parentResp pro
 		return []Node{n}
 	}
 
+	// Skip files which are neither media, subtitles nor companions.
+	if !entry.IsDir() && n.Type == NodeTypeUnknown {
+		n.Error = fmt.Errorf("%w: unknown file type", ErrIgnoredPath)
+		return []Node{n}
+	}
+
 	log.Debug().Str("path", path).Msgf("scanning")
+	ctx = provider.WithTraceEntry(ctx, path)
 
 	// query default to file or directory name
 	query := entry.Name()
@@ -193,6 +232,9 @@ func (g *generic) walk(path string, entry fs.DirEntry, depth int, parentResp pro
 
 		// Parent response
 		Response: parentResp,
+		Parent:   parentReq,
+
+		Scorer: g.options.Scorer,
 	}
 
 	log.Debug().Str("path", path).Str("query", req.Query).Int("Year", info.Year).Interface("info", info).Msg("parsed media info")
@@ -231,25 +273,43 @@ func (g *generic) walk(path string, entry fs.DirEntry, depth int, parentResp pro
 	log.Debug().Str("language", req.QueryLanguage).Float64("confidence", confidence).Msgf("detected language")
 
 	var resp provider.Response
+	var childReq *provider.Request
 	if g.options.StripComponents <= depth {
 		// Query the providers with the parsed information.
-		resp, err = g.Find(req)
+		provider.Trace(ctx, "%s: query %q, year %d, season %d, episode %d, language %q", path, req.Query, req.Year, info.Season, info.Episode, req.QueryLanguage)
+		resp, err = g.Find(ctx, req)
 		if err != nil {
 			// slog.Info("found", "old", n.PathOld, "new", n.PathNew)
 			n.Error = fmt.Errorf("failed to find media: %w", err)
+			provider.Trace(ctx, "%s: %v", path, n.Error)
+			if errors.Is(err, ErrNeedsReview) {
+				// Keep the weak match, its candidates are reported for review.
+				n.Response = resp
+			}
 
 			// log.Err(err).Str("path", path).Msg("processed")
 			return []Node{n}
 		}
 
 		log.Debug().Int("id", resp.GetID()).Str("name", resp.GetName()).Int("year", resp.GetDate().Year()).Str("type", fmt.Sprintf("%T", resp)).Msgf("found    %s", path)
+		provider.Trace(ctx, "%s: found %s %q (%d) %s:%d", path, provider.MediaTypeOf(resp), resp.GetName(), resp.GetDate().Year(), resp.GetProvider(), resp.GetID())
 
 		n.Response = resp
+
+		if episode, ok := resp.(provider.ResponseTVEpisode); ok && info.EpisodeEnd > info.Episode {
+			// Multi-episode file, resolve every episode of the range.
+			n.Episodes, err = findTVEpisodeRange(episode, info.EpisodeEnd)
+			if err != nil {
+				n.Error = fmt.Errorf("failed to find media: %w", err)
+				return []Node{n}
+			}
+		}
 		// This is a directory, continue walking.
 		// Enforce the detected language for child entries, as this is more accurate since
 		// language was detected over all child entries.
+		// The request is passed along to child entries, as the response is shared with their siblings.
 		req.QueryLanguage = childLang
-		resp.SetRequest(req)
+		childReq = &req
 	} else {
 		log.Debug().Msgf("skipping entry due to strip components setting (depth %d, strip %d): %s", depth, g.options.StripComponents, path)
 	}
@@ -272,35 +332,77 @@ func (g *generic) walk(path string, entry fs.DirEntry, depth int, parentResp pro
 	// Backtrack if we detect a different media type than the one we are looking for.
 
 	var nodes []Node
-	for _, nextEntry := range dirs {
+	if g.options.Directories && resp != nil {
+		// Return the directory itself, so it can be renamed as a whole.
+		nodes = append(nodes, n)
+	}
+	for _, childNodes := range g.walkChildren(ctx, path, dirs, depth, resp, childReq) {
+		nodes = append(nodes, childNodes...)
+	}
+
+	return attachCompanions(path, nodes)
+}
+
+// walkChildren walks the given entries of the directory at path and returns their nodes, in the order of the entries.
+// Entries are handed to idle workers when some are available and walked by the caller otherwise,
+// this bounds the number of concurrent walks to the jobs option across the whole tree.
+func (g *generic) walkChildren(ctx context.Context, path string, entries []os.DirEntry, depth int, parentResp provider.Response, parentReq *provider.Request) [][]Node {
+	results := make([][]Node, len(entries))
+
+	var wg sync.WaitGroup
+	for i, entry := range entries {
 		// Build the next path as: current path + entry name.
-		nextPath := filepath.Join(path, nextEntry.Name())
+		nextPath := filepath.Join(path, entry.Name())
 		// TODO: allow for non-recursive scan
-		childNodes := g.walk(nextPath, nextEntry, depth, resp)
-		if childNodes == nil {
-			continue
+
+		select {
+		case g.workers <- struct{}{}:
+			wg.Go(func() {
+				defer func() { <-g.workers }()
+				results[i] = g.walk(ctx, nextPath, entry, depth, parentResp, parentReq)
+			})
+		default:
+			results[i] = g.walk(ctx, nextPath, entry, depth, parentResp, parentReq)
 		}
-		nodes = append(nodes, childNodes...)
 	}
+	wg.Wait()
 
-	return nodes
+	return results
 }
 
 // Find queries all providers in order until one returns a valid response.
 // It tries each provider sequentially and returns the first successful result.
-// If all providers fail, it returns an error.
-func (g *generic) Find(req provider.Request) (provider.Response, error) {
+// When the only matches are not confident enough, the first one is returned along with an ErrNeedsReview error.
+// If all providers fail, it returns an error, which is retryable when any provider failed on a transient error.
+func (g *generic) Find(ctx context.Context, req provider.Request) (provider.Response, error) {
+	var retryErr error
+	var reviewResp provider.Response
+	var reviewErr error
 	for _, p := range g.providers {
-		resp, err := g.find(p, req)
+		resp, err := g.find(ctx, p, req)
 		if err != nil {
 			log.Debug().Err(err).Str("provider", p.Name()).Msg("provider search failed")
+			if errors.Is(err, ErrNeedsReview) && reviewErr == nil {
+				reviewResp, reviewErr = resp, err
+			}
+			if errors.Is(err, ErrRetryable) && retryErr == nil {
+				retryErr = err
+			}
 			continue
 		}
 
 		return resp, nil
 	}
 
-	return nil, fmt.Errorf("no result")
+	if reviewErr != nil {
+		return reviewResp, reviewErr
+	}
+
+	if retryErr != nil {
+		return nil, retryErr
+	}
+
+	return nil, provider.ErrNoResult
 }
 
 // find queries a single provider with the given request and returns a response.
@@ -312,7 +414,7 @@ func (g *generic) Find(req provider.Request) (provider.Response, error) {
 // - For Movie: returns the movie response directly
 // - For TV show: searches for seasons or episodes based on available information
 // - For movies: returns the movie response directly
-func (g *generic) find(p provider.Interface, req provider.Request) (provider.Response, error) {
+func (g *generic) find(ctx context.Context, p provider.Interface, req provider.Request) (provider.Response, error) {
 	log.Debug().Str("query", req.Query).Int("year", req.Year).Str("language", req.QueryLanguage).Int("season", req.Info.Season).Int("episode", req.Info.Episode).Str("response", fmt.Sprintf("%T", req.Response)).Msgf("finding media")
 	if req.Response == nil {
 		// Processing a top level media (no previous response).
@@ -324,19 +426,31 @@ func (g *generic) find(p provider.Interface, req provider.Request) (provider.Res
 
 		if req.Info.Season > 0 || req.Info.Episode > 0 {
 			// Search for TV show season or episode.
-			tv, _, err := p.SearchTV(req)
+			provider.Trace(ctx, "%s: season or episode given, searching TV shows only", p.Name())
+			tv, _, err := p.SearchTV(ctx, req)
 			if err != nil {
 				return nil, err
 			}
-			return g.findTVChild(p, tv, req)
+			provider.Trace(ctx, "%s: TV show %q (%d) %s:%d", p.Name(), tv.GetName(), tv.GetDate().Year(), tv.GetProvider(), tv.GetID())
+			err = g.checkConfidence(ctx, tv)
+			if err != nil {
+				// The show is returned for review, rather than one of its episodes.
+				return tv, err
+			}
+			return g.findTVChild(ctx, p, tv, req)
 		}
 
 		// Search for Movie or TV show.
-		return g.searchByYearOrPopularity(p, req)
+		resp, err := g.searchByYearOrPopularity(ctx, p, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp, g.checkConfidence(ctx, resp)
 	}
 
 	// Change language of the response to match the request language.
-	resp, err := req.Response.InLanguage(req)
+	provider.Trace(ctx, "%s: using the match of the parent entry, %s %q", p.Name(), provider.MediaTypeOf(req.Response), req.Response.GetName())
+	resp, err := req.Response.InLanguage(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -353,7 +467,7 @@ func (g *generic) find(p provider.Interface, req provider.Request) (provider.Res
 		// TODO: implement child search order
 		// if isDir -> search for season then episode
 		// else -> search for episode then season
-		return g.findTVChild(p, r, req)
+		return g.findTVChild(ctx, p, r, req)
 	case provider.ResponseTVSeason:
 		if req.Info.Episode > 0 {
 			// Parent is a season, get the episode by number.
@@ -373,24 +487,41 @@ func (g *generic) find(p provider.Interface, req provider.Request) (provider.Res
 		// newReq := g.usePreviousLanguage(req)
 		newReq := req
 		newReq.Response = r.GetSeason()
-		return g.Find(newReq)
+		return g.Find(ctx, newReq)
 	}
 
 	return nil, fmt.Errorf("find: unsupported response media type: %T", req.Response)
 }
 
+// checkConfidence returns an ErrNeedsReview error when the confidence of the match resp is below the minimum confidence.
+// Matches of unknown confidence are accepted.
+func (g *generic) checkConfidence(ctx context.Context, resp provider.Response) error {
+	c, ok := provider.CandidateOf(resp)
+	if !ok || c.Breakdown == nil {
+		return nil
+	}
+
+	if c.Breakdown.Confidence < g.options.MinConfidence {
+		provider.Trace(ctx, "confidence %.2f is below the minimum of %.2f, the match needs review", c.Breakdown.Confidence, g.options.MinConfidence)
+		return fmt.Errorf("%w: confidence %.2f below %.2f", ErrNeedsReview, c.Breakdown.Confidence, g.options.MinConfidence)
+	}
+	provider.Trace(ctx, "confidence %.2f (minimum %.2f)", c.Breakdown.Confidence, g.options.MinConfidence)
+
+	return nil
+}
+
 // searchByYearOrPopularity searches for both movie and TV show and returns the best match.
 // This method is used when we have ambiguous media that could be either a movie or TV show.
 // It queries both endpoints and compares using a combined score that considers both
 // title similarity, year proximity, and popularity to determine the best match.
-func (g *generic) searchByYearOrPopularity(p provider.Interface, req provider.Request) (provider.Response, error) {
-	movie, movieScore, err := p.SearchMovie(req)
+func (g *generic) searchByYearOrPopularity(ctx context.Context, p provider.Interface, req provider.Request) (provider.Response, error) {
+	movie, movieScore, err := p.SearchMovie(ctx, req)
 	if err != nil && !errors.Is(err, provider.ErrNoResult) {
 		// Ignore no result error, as we want to try TV show search as well.
 		return nil, err
 	}
 
-	tvshow, tvScore, err := p.SearchTV(req)
+	tvshow, tvScore, err := p.SearchTV(ctx, req)
 	if err != nil && !errors.Is(err, provider.ErrNoResult) {
 		// Ignore no result error, as we want to try movie search as well.
 		return nil, err
@@ -398,16 +529,19 @@ func (g *generic) searchByYearOrPopularity(p provider.Interface, req provider.Re
 
 	if movie == nil && tvshow == nil {
 		// No result from either search.
+		provider.Trace(ctx, "%s: no movie nor TV show found", p.Name())
 		return nil, provider.ErrNoResult
 	}
 
 	if movie == nil {
 		// Only TV show found.
+		provider.Trace(ctx, "%s: no movie found, TV show %q (%d) chosen with score %.2f", p.Name(), tvshow.GetName(), tvshow.GetDate().Year(), tvScore)
 		return tvshow, nil
 	}
 
 	if tvshow == nil {
 		// Only movie found.
+		provider.Trace(ctx, "%s: no TV show found, movie %q (%d) chosen with score %.2f", p.Name(), movie.GetName(), movie.GetDate().Year(), movieScore)
 		return movie, nil
 	}
 
@@ -426,11 +560,19 @@ func (g *generic) searchByYearOrPopularity(p provider.Interface, req provider.Re
 		Float64("tv_score", tvScore).
 		Msg("comparing movie and tv show by combined score")
 
+	// Both movies and TV shows are candidates of the best match.
+	// Responses are shared by sibling entries, which merge the same candidates again.
+	candidates := provider.MergeCandidates(movie.GetCandidates(), tvshow.GetCandidates())
+
 	if movieScore <= tvScore {
 		// Movie has better (lower) combined score.
+		provider.Trace(ctx, "%s: movie %q (%d) scored %.2f, TV show %q (%d) scored %.2f, movie chosen", p.Name(), movie.GetName(), movie.GetDate().Year(), movieScore, tvshow.GetName(), tvshow.GetDate().Year(), tvScore)
+		movie.SetCandidates(candidates)
 		return movie, nil
 	}
 
 	// TV show has better (lower) combined score.
+	provider.Trace(ctx, "%s: movie %q (%d) scored %.2f, TV show %q (%d) scored %.2f, TV show chosen", p.Name(), movie.GetName(), movie.GetDate().Year(), movieScore, tvshow.GetName(), tvshow.GetDate().Year(), tvScore)
+	tvshow.SetCandidates(candidates)
 	return tvshow, nil
 }
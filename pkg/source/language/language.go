@@ -83,19 +83,14 @@ func Detect(req provider.Request, entries []os.DirEntry) (string, float64, strin
 		// return strings.ToLower(lang), confidence
 	}
 
-	if req.Response == nil {
+	if req.Response == nil || req.Parent == nil {
 		// Use default language for initial search, to let the provider decide the best match.
 		return "", -1, childLang
 	}
 
 	// Use previously detected language
 	// Having a previous request means we are already down in the tree.
-	prevReq := req.Response.GetRequest()
-	if prevReq == nil {
-		return "", -1, childLang
-	}
-
-	return prevReq.QueryLanguage, -1, childLang
+	return req.Parent.QueryLanguage, -1, childLang
 
 	// lang, confidence := Lingua(req.Query)
 	// return strings.ToLower(lang), confidence
@@ -15,6 +15,10 @@ var (
 	// from episode file names for episode number detection.
 	// It works for common episode naming patterns like "01 - Episode Title", "1 - Episode Title", "Episode Title - 01" etc.
 	episodeRegex = regexp.MustCompile(`(?:^|\W)([0-9]{1,})\W`)
+	// specialsRegex is a regular expression used to detect specials (season 0)
+	// from season directory or episode file names.
+	// It works for explicit season 0 like "Season 0", "S00", "S00E03", "0x03" and names like "Specials" or "Christmas Special".
+	specialsRegex = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(s(?:eason)?[ ._-]?0+(?:e[0-9]+)?|0+x[0-9]+|specials?)(?:[^a-z0-9]|$)`)
 )
 
 // extractNumber extracts a numeric value from the input string using the provided regex.
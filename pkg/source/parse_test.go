@@ -41,6 +41,21 @@ var testData = []struct {
 			{input: "03 Another Title", expected: "03"},
 		},
 	},
+	{
+		name:  "specialsRegex",
+		regex: specialsRegex,
+		cases: []testCase{
+			{input: "Specials", expected: "Specials"},
+			{input: "Season 0", expected: "Season 0"},
+			{input: "Season 00", expected: "Season 00"},
+			{input: "Show.S00E03.720p.mkv", expected: "S00E03"},
+			{input: "Show.0x03.mkv", expected: "0x03"},
+			{input: "Show - Special - Christmas Episode.mkv", expected: "Special"},
+			{input: "Season 10", expected: ""},
+			{input: "Show.S01E03.mkv", expected: ""},
+			{input: "Specialist", expected: ""},
+		},
+	},
 }
 
 func TestParse(t *testing.T) {
@@ -4,10 +4,12 @@
 package source
 
 import (
+	"context"
 	"io/fs"
 
 	"github.com/TheoBrigitte/evansky/pkg/parser"
 	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/score"
 )
 
 // Source defines the interface for media source scanners.
@@ -16,7 +18,7 @@ import (
 type Source interface {
 	// Scan processes the given path using the provided metadata providers and options,
 	// returning a list of nodes that represent potential rename operations.
-	Scan(string, []provider.Interface, Options) []Node
+	Scan(context.Context, string, []provider.Interface, Options) []Node
 }
 
 type NodeType int
@@ -25,6 +27,7 @@ const (
 	NodeTypeUnknown NodeType = iota
 	NodeTypeMedia
 	NodeTypeSubtitle
+	NodeTypeCompanion
 )
 
 // Node represents a single file or directory rename operation.
@@ -43,16 +46,22 @@ type Node struct {
 	Path string
 	// Responses holds metadata responses from provider.
 	Response provider.Response
+	// Episodes holds every episode of a multi-episode file (e.g. S01E01-E02), in order.
+	Episodes []provider.ResponseTVEpisode
+	// Companions holds the companion files and extras directories attached to this media,
+	// they are renamed along with it.
+	Companions []Node
 }
 
 // Options configures the behavior of source scanning operations.
 type Options struct {
-	ExcludeGlob  []string // A glob pattern to exclude files or directories
-	ExcludeRegex string   // A regex pattern to exclude files or directories
-	IncludeGlob  []string // A glob pattern to include files or directories
-	IncludeRegex string   // A regex pattern to include files or directories
-	MediaExts    []string
-	SubtitleExts []string
+	ExcludeGlob   []string // A glob pattern to exclude files or directories
+	ExcludeRegex  string   // A regex pattern to exclude files or directories
+	IncludeGlob   []string // A glob pattern to include files or directories
+	IncludeRegex  string   // A regex pattern to include files or directories
+	MediaExts     []string
+	SubtitleExts  []string
+	CompanionExts []string // Extensions of companion files carried along with media (nfo, images, external audio, etc.)
 	// TODO: add setting to prefer file name preference over parent directories when finding a match
 	Recursive     bool   // Whether to scan directories recursively
 	Query         string // Query override for metadata retrieval
@@ -62,17 +71,22 @@ type Options struct {
 	MaxDepth      int    // Maximum directory depth to process
 	// TODO: might be an options just for renaming and not sourcing
 	SkipDirectories bool // Whether to skip processing directories themselves
+	Directories     bool // Whether to return nodes for directories themselves, before their content
 	StripComponents int  // Number of leading path components to strip from source paths
 	TitleRegex      string
+	Jobs            int          // Maximum number of entries scanned concurrently, 1 scans sequentially
+	Scorer          score.Scorer // Scorer of search results, score.Default when nil
+	MinConfidence   float64      // Minimum confidence of a match, entries below it need review instead of being renamed
 }
 
 // Scan is a convenience function that creates a generic source scanner and
 // performs a scan operation with the given parameters.
 // It returns a list of nodes representing potential rename operations.
-func Scan(path string, providers []provider.Interface, o Options) []Node {
+// Scanning stops when ctx is done, entries which were not scanned hold the context error.
+func Scan(ctx context.Context, path string, providers []provider.Interface, o Options) []Node {
 	s := New(path, providers, o)
 
-	nodes, err := s.scan()
+	nodes, err := s.scan(ctx)
 	if err != nil {
 		n := Node{
 			Path:  path,
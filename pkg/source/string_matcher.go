@@ -1,59 +1,16 @@
 package source
 
 import (
-	"strings"
-
-	"github.com/adrg/strutil"
-	"github.com/adrg/strutil/metrics"
+	"github.com/TheoBrigitte/evansky/pkg/score"
 )
 
-func BetterMatch(a, b string, previousScore float64) (bool, float64) {
-	// Normalize strings for better comparison
-	a = normalizeString(a)
-	b = normalizeString(b)
-
-	// s := levenshtein(a, b)
-	// isBetter := newScore < previousScore
+// minMatchScore is the score above which a match is good enough to stop looking for a better one elsewhere.
+const minMatchScore = 0.8
 
-	// newScore := jaccard(a, b)
-	// newScore := overlap(a, b)
-	// newScore := smithWatermanGotoh(a, b, previousScore)
-	newScore := jaroWinkler(a, b)
+// BetterMatch returns the similarity of a and b according to s, and whether it is higher than previousScore.
+func BetterMatch(s score.Scorer, a, b string, previousScore float64) (bool, float64) {
+	newScore := s.Similarity(a, b)
 	isBetter := newScore > previousScore
 
 	return isBetter, newScore
 }
-
-// normalizeString normalizes a string for better matching by converting to lowercase and trimming whitespace
-func normalizeString(s string) string {
-	s = strings.TrimSpace(s)
-	s = strings.ToLower(s)
-	return s
-}
-
-// Alternative string similarity metrics that can be used for comparison:
-//
-//func overlap(a, b string) float64 {
-//	return strutil.Similarity(a, b, metrics.NewOverlapCoefficient())
-//}
-//
-//// jaccard returns the Jaccard similarity between two strings as a float64.
-//func jaccard(a, b string) float64 {
-//	return strutil.Similarity(a, b, metrics.NewJaccard())
-//}
-//
-//// levenshtein returns the Levenshtein distance between two strings as a float64.
-//func levenshtein(a, b string) float64 {
-//	return float64(gstr.Levenshtein(a, b, 1, 1, 1))
-//}
-//
-//func smithWatermanGotoh(a, b string, previousScore float64) float64 {
-//	score := strutil.Similarity(a, b, metrics.NewSmithWatermanGotoh())
-//	return score
-//}
-
-// jaroWinkler returns the Jaro-Winkler similarity between two strings as a float64 (0-1 range).
-// Higher scores indicate better matches. This is ideal for comparing titles and names.
-func jaroWinkler(a, b string) float64 {
-	return strutil.Similarity(a, b, metrics.NewJaroWinkler())
-}
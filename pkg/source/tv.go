@@ -1,21 +1,63 @@
 package source
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/score"
 )
 
+// FindTVChild finds the season or episode of the TV show tv which the node n is about, along with every episode of a multi-episode file.
+// It is used to match n to another show than the one it was found with, the season and episode are taken from the name of n.
+func FindTVChild(ctx context.Context, providers []provider.Interface, o Options, n Node, tv provider.ResponseTV) (Node, error) {
+	i := slices.IndexFunc(providers, func(p provider.Interface) bool { return p.Name() == tv.GetProvider() })
+	if i < 0 {
+		return n, fmt.Errorf("provider %s not found", tv.GetProvider())
+	}
+
+	req := provider.Request{
+		Query:               n.Info.Title,
+		Year:                n.Info.Year,
+		QueryLanguage:       o.QueryLanguage,
+		DestinationLanguage: o.Language,
+		Info:                n.Info,
+		Entry:               n.Entry,
+		Response:            tv,
+		Scorer:              o.Scorer,
+	}
+
+	g := New(n.Path, providers, o)
+	resp, err := g.findTVChild(ctx, providers[i], tv, req)
+	if err != nil {
+		return n, err
+	}
+
+	n.Response = resp
+	n.Episodes = nil
+	if episode, ok := resp.(provider.ResponseTVEpisode); ok && n.Info.EpisodeEnd > n.Info.Episode {
+		n.Episodes, err = findTVEpisodeRange(episode, n.Info.EpisodeEnd)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
 // findTVChild finds a TV show child (season or episode) based on the request information.
 // It handles different scenarios:
 // - Season number provided: gets the specific season, optionally with episode
+// - Specials (S00E03, Specials or Extras directory, etc.): gets season 0, optionally with episode
 // - Episode number only: searches across all seasons for the episode
 // - Title only: attempts season number detection or searches by name
-func (g *generic) findTVChild(p provider.Interface, tv provider.ResponseTV, req provider.Request) (provider.Response, error) {
-	resp, err := g.findTVChildWithNumber(p, tv, req)
+func (g *generic) findTVChild(ctx context.Context, p provider.Interface, tv provider.ResponseTV, req provider.Request) (provider.Response, error) {
+	resp, err := g.findTVChildWithNumber(ctx, p, tv, req)
 	if err != nil {
 		return nil, err
 	}
@@ -33,51 +75,75 @@ func (g *generic) findTVChild(p provider.Interface, tv provider.ResponseTV, req
 				return nil, fmt.Errorf("findTVChild: cannot detect season number in title: %s", req.Entry.Name())
 			}
 			req.Info.Season = seasonNumber
+			provider.Trace(ctx, "season number %d detected in directory name", seasonNumber)
 		} else {
-			// Try to detect episode number from directory name
+			// Try to detect episode number from file name, otherwise search for the episode by name
 			episodeNumber, err := extractNumber(req.Entry.Name(), episodeRegex)
-			if err != nil {
-				return nil, fmt.Errorf("findTVChild: cannot detect episode number in title: %s", req.Entry.Name())
+			if err == nil {
+				req.Info.Episode = episodeNumber
+				req.Info.Season = -1 // Invalidate season number if episode number is detected
+				provider.Trace(ctx, "episode number %d detected in file name", episodeNumber)
 			}
-			req.Info.Episode = episodeNumber
-			req.Info.Season = -1 // Invalidate season number if episode number is detected
 		}
 		if req.Info.Season > 0 || req.Info.Episode > 0 {
-			return g.findTVChildWithNumber(p, tv, req)
+			return g.findTVChildWithNumber(ctx, p, tv, req)
 		}
 
 		// Search for season or episode by name
-		return g.findTVSeasonOrEpisode(p, tv.GetSeasons(), req)
+		seasons, err := tv.GetSeasons(ctx)
+		if err != nil {
+			return nil, err
+		}
+		provider.Trace(ctx, "matching %q against the names of %d seasons and their episodes", req.Entry.Name(), len(seasons))
+		return g.findTVSeasonOrEpisode(p, seasons, req)
 	}
 
 	return nil, fmt.Errorf("findTVChild: no season or episode information")
 }
 
-func (g *generic) findTVChildWithNumber(p provider.Interface, tv provider.ResponseTV, req provider.Request) (provider.Response, error) {
-	if req.Info.Season > 0 {
-		// Prefer season number if available
+func (g *generic) findTVChildWithNumber(ctx context.Context, p provider.Interface, tv provider.ResponseTV, req provider.Request) (provider.Response, error) {
+	special := req.Info.Season == 0 && isSpecials(req)
+	if req.Info.Season > 0 || special {
+		// Prefer season number if available, specials are season 0
 
 		// req = g.usePreviousLanguage(req)
 
 		// Get season by number
-		season, err := tv.GetSeason(req.Info.Season)
+		season, err := tv.GetSeason(ctx, req.Info.Season)
 		if err != nil {
 			return nil, err
 		}
 
 		if req.Info.Episode > 0 {
 			// Season and episode number provided, get the episode
+			provider.Trace(ctx, "getting episode %d of season %d", req.Info.Episode, req.Info.Season)
 			return season.GetEpisode(req.Info.Episode)
 		}
 
+		if special && !req.Entry.IsDir() {
+			// Special without episode number, search for the episode by name
+			provider.Trace(ctx, "matching %q against the names of the specials", req.Entry.Name())
+			return g.findTVEpisode(p, []provider.ResponseTVSeason{season}, req)
+		}
+
 		// Only season number provided, return the season
+		provider.Trace(ctx, "getting season %d", req.Info.Season)
 		return season, nil
 	}
 
 	if req.Info.Episode > 0 {
 		// Only episode number provided, search for episode across all seasons
 		// req = g.usePreviousLanguage(req)
-		return g.findTVEpisode(p, tv.GetSeasons(), req)
+		seasons, err := tv.GetSeasons(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if req.Info.Season < 0 {
+			provider.Trace(ctx, "getting episode %d by absolute number across %d seasons", req.Info.Episode, len(seasons))
+		} else {
+			provider.Trace(ctx, "getting episode %d from the first of %d seasons having it", req.Info.Episode, len(seasons))
+		}
+		return g.findTVEpisode(p, seasons, req)
 	}
 
 	// TODO: return a concrete error type to distinguish no match found from other errors
@@ -87,30 +153,20 @@ func (g *generic) findTVChildWithNumber(p provider.Interface, tv provider.Respon
 // findTVSeasonOrEpisode finds a TV show season or episode based on the request information.
 // It finds the best match among all seasons and episodes, by
 // comparing the request title against season names and episode names.
+// Specials are only considered when no regular season or episode is a good match.
 func (g *generic) findTVSeasonOrEpisode(p provider.Interface, seasons []provider.ResponseTVSeason, req provider.Request) (provider.Response, error) {
 	log.Debug().Int("seasons", len(seasons)).Int("season", req.Info.Season).Int("episode", req.Info.Episode).Msgf("findTVSeasonOrEpisode: searching for season or episode matching title: %s", req.Entry.Name())
 
-	// Search for season or episode by name using.
-	var bestMatch provider.Response
-	var bestScore float64 = -1
-	// seasons := make([]gotmdb.TVSeason, 0, len(show.Seasons))
-	for _, season := range seasons {
-		isBetter, seasonScore := BetterMatch(req.Entry.Name(), season.GetName(), bestScore)
-		if isBetter {
-			bestScore = seasonScore
-			bestMatch = season
-		}
-
-		for _, episode := range season.GetEpisodes() {
-			isBetter, episodeScore := BetterMatch(req.Entry.Name(), episode.GetName(), bestScore)
-			if isBetter {
-				bestScore = episodeScore
-				bestMatch = episode
-			}
-		}
+	// Search for season or episode by name, regular seasons first.
+	regular, specials := splitSpecials(seasons)
+	bestMatch, bestScore := bestTVMatch(req.GetScorer(), req.Entry.Name(), regular, true, nil, -1)
+	if bestScore < minMatchScore {
+		// Weak match, this could be a special.
+		bestMatch, bestScore = bestTVMatch(req.GetScorer(), req.Entry.Name(), specials, true, bestMatch, bestScore)
 	}
 
 	if bestMatch != nil {
+		log.Debug().Float64("score", bestScore).Str("name", bestMatch.GetName()).Msg("findTVSeasonOrEpisode: best match")
 		return bestMatch, nil
 	}
 
@@ -138,17 +194,12 @@ func (g *generic) findTVEpisode(p provider.Interface, seasons []provider.Respons
 		return nil, fmt.Errorf("findTVEpisode: no episode information")
 	}
 
-	// Search for episode by finding the best match.
-	var bestMatch provider.Response
-	var bestScore float64 = -1
-	for _, season := range seasons {
-		for _, episode := range season.GetEpisodes() {
-			isBetter, episodeScore := BetterMatch(name, episode.GetName(), bestScore)
-			if isBetter {
-				bestScore = episodeScore
-				bestMatch = episode
-			}
-		}
+	// Search for episode by finding the best match, regular seasons first.
+	regular, specials := splitSpecials(seasons)
+	bestMatch, bestScore := bestTVMatch(req.GetScorer(), name, regular, false, nil, -1)
+	if bestScore < minMatchScore {
+		// Weak match, this could be a special.
+		bestMatch, _ = bestTVMatch(req.GetScorer(), name, specials, false, bestMatch, bestScore)
 	}
 
 	if bestMatch != nil {
@@ -160,7 +211,9 @@ func (g *generic) findTVEpisode(p provider.Interface, seasons []provider.Respons
 
 func (g *generic) findTVEpisodeInSeasons(p provider.Interface, seasons []provider.ResponseTVSeason, req provider.Request) (provider.Response, error) {
 	// Episode number provided, get the episode from the first season that has it.
-	for _, season := range seasons {
+	// Specials are tried last, as a special rarely goes without its season number.
+	regular, specials := splitSpecials(seasons)
+	for _, season := range append(regular, specials...) {
 		// Try to get the episode from this season
 		resp, err := season.GetEpisode(req.Info.Episode)
 		if err != nil && errors.Is(err, provider.ErrNoResult) {
@@ -204,3 +257,67 @@ func (g *generic) findTVEpisodeAbsoluteNumber(p provider.Interface, seasons []pr
 
 	return nil, fmt.Errorf("findTVEpisodeAbsoluteNumber: episode %d not found", req.Info.Episode)
 }
+
+// findTVEpisodeRange returns every episode from first up to the episode numbered last, in order.
+func findTVEpisodeRange(first provider.ResponseTVEpisode, last int) ([]provider.ResponseTVEpisode, error) {
+	episodes := []provider.ResponseTVEpisode{first}
+
+	season := first.GetSeason()
+	for number := first.GetEpisodeNumber() + 1; number <= last; number++ {
+		episode, err := season.GetEpisode(number)
+		if err != nil {
+			return nil, fmt.Errorf("findTVEpisodeRange: episode %d: %w", number, err)
+		}
+		episodes = append(episodes, episode)
+	}
+
+	return episodes, nil
+}
+
+// isSpecials reports whether req is about the specials (season 0) of a TV show.
+// This is the case for an explicit season 0 (S00E03, 0x03, Season 0), names mentioning specials and Extras directories.
+func isSpecials(req provider.Request) bool {
+	name := req.Entry.Name()
+	if req.Entry.IsDir() && strings.EqualFold(name, "extras") {
+		return true
+	}
+
+	return specialsRegex.MatchString(name)
+}
+
+// splitSpecials splits seasons into regular seasons and specials (season 0).
+func splitSpecials(seasons []provider.ResponseTVSeason) (regular, specials []provider.ResponseTVSeason) {
+	for _, season := range seasons {
+		if season.GetSeasonNumber() == 0 {
+			specials = append(specials, season)
+			continue
+		}
+		regular = append(regular, season)
+	}
+
+	return regular, specials
+}
+
+// bestTVMatch returns the season or episode whose name best matches name, starting from bestMatch and bestScore.
+// Season names are only compared when withSeasons is set.
+func bestTVMatch(s score.Scorer, name string, seasons []provider.ResponseTVSeason, withSeasons bool, bestMatch provider.Response, bestScore float64) (provider.Response, float64) {
+	for _, season := range seasons {
+		if withSeasons {
+			isBetter, seasonScore := BetterMatch(s, name, season.GetName(), bestScore)
+			if isBetter {
+				bestScore = seasonScore
+				bestMatch = season
+			}
+		}
+
+		for _, episode := range season.GetEpisodes() {
+			isBetter, episodeScore := BetterMatch(s, name, episode.GetName(), bestScore)
+			if isBetter {
+				bestScore = episodeScore
+				bestMatch = episode
+			}
+		}
+	}
+
+	return bestMatch, bestScore
+}
@@ -1,66 +1,67 @@
 package util
 
 import (
-	"math"
+	"slices"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/TheoBrigitte/evansky/pkg/provider"
-	"github.com/TheoBrigitte/evansky/pkg/source"
+	"github.com/TheoBrigitte/evansky/pkg/score"
 )
 
-// BestMatch compares a list of elements to a search request and returns the closest match based on title similarity, year proximity, and popularity.
-// TODO: add error in return to handle cases where no match is found
-func BestMatch[E any, R provider.Response](req provider.Request, elements []E, newE func(E, provider.Request) (R, error)) (R, float64) {
+// BestMatch compares a list of elements to a search request and returns the closest match according to the scorer of the request,
+// based on title similarity, year proximity, and popularity.
+// Elements which fail to convert are skipped, when none converts the last error is returned.
+func BestMatch[E any, R provider.Response](req provider.Request, elements []E, newE func(E, provider.Request) (R, error)) (R, float64, error) {
+	var lastErr error = provider.ErrNoResult
 	var bestScore float64 = -1
 	var bestTitleScore float64 = 0
 	var closestMatch R
+	var candidates []provider.Candidate
 
+	scorer := req.GetScorer()
 	for index, t := range elements {
-		var yearScore float64
-
 		e, err := newE(t, req)
 		if err != nil {
 			log.Warn().Err(err).Msgf("failed to convert element to response: %v", t)
+			lastErr = err
+			continue
 		}
 
-		// Only calculate year score if year is provided
-		if req.Year > 0 {
-			yearScore = computeClosetYearScore(req.Year, e.GetDate().Year(), index)
-		} else {
-			// When no year is provided, use index as a small tiebreaker
-			yearScore = float64(index)
-		}
-
-		// Calculate title similarity (higher is better, 0-1 range)
-		_, titleScore := source.BetterMatch(req.Query, e.GetName(), 0)
+		// Results without a date are of year 1, far from the searched year, as in the legacy score,
+		// rather than of unknown year, which would give them full confidence.
+		b := scorer.Score(score.Match{
+			Query:      req.Query,
+			Year:       req.Year,
+			Name:       e.GetName(),
+			ResultYear: e.GetDate().Year(),
+			Popularity: e.GetPopularity(),
+			Index:      index,
+		})
 
-		// Calculate popularity score (lower is better, inverted so higher popularity = lower score)
-		// Normalize popularity to 0-100 range and invert
-		popularity := e.GetPopularity()
-		popularityScore := 100.0 - float64(popularity)
+		log.Debug().Msgf("comparing %T title=%s provider=%s providerId=%d date=%s titleSimilarity=%f titleScore=%f yearScore=%f popularityScore=%f combinedScore=%f",
+			t, e.GetName(), e.GetProvider(), e.GetID(), e.GetDate(), b.TitleSimilarity, b.Title, b.Year, b.Popularity, b.Total)
 
-		// Combined score: weighted sum where title is primary, year and popularity are secondary (lower is better)
-		// Title mismatch is weighted heavily (1000x) so better title matches almost always win
-		// Year score and popularity score matter for breaking ties
-		combinedScore := (1.0-titleScore)*1000.0 + yearScore + popularityScore
+		candidates = append(candidates, provider.NewCandidate(e, b))
 
-		log.Debug().Msgf("comparing %T title=%s provider=%s providerId=%d date=%s yearScore=%f titleScore=%f popularity=%d popularityScore=%f combinedScore=%f",
-			t, e.GetName(), e.GetProvider(), e.GetID(), e.GetDate(), yearScore, titleScore, popularity, popularityScore, combinedScore)
-
-		if bestScore == -1 || combinedScore < bestScore {
-			bestScore = combinedScore
-			bestTitleScore = titleScore
+		if bestScore == -1 || b.Total < bestScore {
+			bestScore = b.Total
+			bestTitleScore = b.TitleSimilarity
 			closestMatch = e
 		}
 	}
 
+	if bestScore == -1 {
+		var zero R
+		return zero, 0, lastErr
+	}
+
+	// Keep every candidate, so the match can be reviewed and overridden.
+	slices.SortStableFunc(candidates, provider.CompareCandidates)
+	closestMatch.SetCandidates(candidates)
+
 	log.Debug().Msgf("best match: title=%s provider=%s providerId=%d bestScore=%f bestTitleScore=%f",
 		closestMatch.GetName(), closestMatch.GetProvider(), closestMatch.GetID(), bestScore, bestTitleScore)
 
-	return closestMatch, bestScore
-}
-
-func computeClosetYearScore(targetYear int, actualYear int, index int) float64 {
-	return (math.Abs(float64(targetYear-actualYear)) + 1) * (math.Exp(float64(index + 2)))
+	return closestMatch, bestScore, nil
 }
@@ -0,0 +1,37 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/TheoBrigitte/evansky/pkg/provider"
+	"github.com/TheoBrigitte/evansky/pkg/provider/providertest"
+	"github.com/TheoBrigitte/evansky/pkg/util"
+)
+
+func TestBestMatchUndated(t *testing.T) {
+	movies := []providertest.Movie{
+		{ID: 1, Name: "The Matrix", Year: 0},
+		{ID: 2, Name: "The Matrix", Year: 1999},
+	}
+	req := provider.Request{Query: "The Matrix", Year: 1999}
+
+	resp, _, err := util.BestMatch(req, movies, func(m providertest.Movie, req provider.Request) (*providertest.MovieResponse, error) {
+		return providertest.NewMovieResponse(m, req), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetID() != 2 {
+		t.Errorf("expected dated result to be the best match, got %d", resp.GetID())
+	}
+
+	// Undated results are as far as can be from the searched year, they are not confident matches.
+	for _, c := range resp.GetCandidates() {
+		if c.ID == 1 && c.Breakdown.Confidence >= 0.5 {
+			t.Errorf("expected undated result to have a low confidence, got '%f'", c.Breakdown.Confidence)
+		}
+		if c.ID == 2 && c.Breakdown.Confidence != 1 {
+			t.Errorf("expected dated result to have full confidence, got '%f'", c.Breakdown.Confidence)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+//go:build linux
+
+package watcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/rs/zerolog/log"
+)
+
+// watchMask lists the inotify events reporting new or changed entries.
+const watchMask = syscall.IN_CREATE | syscall.IN_MOVED_TO | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY
+
+// notifier reports the entries of the watched directories which changed, using inotify.
+// Directories are watched recursively, a change deep inside an entry is reported as a change of this entry.
+type notifier struct {
+	fd   int
+	file *os.File
+
+	mu      sync.Mutex
+	watches map[int]watch // inotify watch descriptor to watched directory
+
+	events chan string
+	errors chan error
+	done   chan struct{}
+}
+
+// watch is a directory watched by inotify.
+type watch struct {
+	root string // watched directory given by the user
+	dir  string // directory watched by this descriptor, root or one of its subdirectories
+}
+
+func newNotifier(paths []string) (*notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	n := &notifier{
+		fd: fd,
+		// A non blocking file descriptor is handled by the runtime poller, this allows Close to interrupt a pending Read.
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		watches: make(map[int]watch),
+		events:  make(chan string),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+	}
+
+	for _, path := range paths {
+		err := n.addRecursive(path, path)
+		if err != nil {
+			n.file.Close()
+			return nil, err
+		}
+	}
+
+	go n.read()
+
+	return n, nil
+}
+
+// Close stops watching.
+func (n *notifier) Close() error {
+	close(n.done)
+	return n.file.Close()
+}
+
+// addRecursive watches dir and all its subdirectories.
+func (n *notifier) addRecursive(root, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		wd, err := syscall.InotifyAddWatch(n.fd, path, watchMask)
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+
+		n.mu.Lock()
+		n.watches[wd] = watch{root: root, dir: path}
+		n.mu.Unlock()
+
+		return nil
+	})
+}
+
+// read reads inotify events until the notifier is closed.
+func (n *notifier) read() {
+	buf := make([]byte, 4096*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrClosed) {
+				return
+			}
+			n.sendError(fmt.Errorf("failed to read inotify events: %w", err))
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+			if !n.handle(int(event.Wd), event.Mask, name) {
+				return
+			}
+		}
+	}
+}
+
+// handle processes a single inotify event, it returns false once the notifier is closed.
+func (n *notifier) handle(wd int, mask uint32, name string) bool {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		log.Warn().Msg("too many filesystem events, some new entries may be missed")
+		return true
+	}
+
+	n.mu.Lock()
+	w, ok := n.watches[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		// Watched directory was removed.
+		delete(n.watches, wd)
+		ok = false
+	}
+	n.mu.Unlock()
+	if !ok || name == "" {
+		return true
+	}
+
+	path := filepath.Join(w.dir, name)
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		// New directory, watch it along with the subdirectories it may already contain.
+		err := n.addRecursive(w.root, path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Err(err).Str("path", path).Msg("failed to watch new directory")
+		}
+	}
+
+	entry, ok := topLevel(w.root, path)
+	if !ok {
+		return true
+	}
+
+	select {
+	case n.events <- entry:
+		return true
+	case <-n.done:
+		return false
+	}
+}
+
+// sendError reports err unless the notifier is closed.
+func (n *notifier) sendError(err error) {
+	select {
+	case n.errors <- err:
+	case <-n.done:
+	}
+}
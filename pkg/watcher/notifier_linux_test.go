@@ -0,0 +1,43 @@
+//go:build linux
+
+package watcher
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	w := New([]string{root}, Options{IncompleteExts: []string{"part"}})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	handled := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- w.Run(ctx, func(path string) { handled <- path })
+	}()
+
+	// Give the watcher time to start, files written deep inside a new directory are reported as this directory.
+	time.Sleep(100 * time.Millisecond)
+	writeFile(t, filepath.Join(root, "Show", "Season 1", "e01.mkv"), "a")
+
+	select {
+	case path := <-handled:
+		if expected := filepath.Join(root, "Show"); path != expected {
+			t.Errorf("expected %q to be handled, got %q", expected, path)
+		}
+	case <-ctx.Done():
+		t.Fatal("new entry was not handled")
+	}
+
+	cancel()
+	err := <-errs
+	if err != nil {
+		t.Errorf("expected watcher to stop without error, got %v", err)
+	}
+}
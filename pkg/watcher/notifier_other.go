@@ -0,0 +1,21 @@
+//go:build !linux
+
+package watcher
+
+import "errors"
+
+// notifier reports the entries of the watched directories which changed.
+// It is only implemented on Linux.
+type notifier struct {
+	events chan string
+	errors chan error
+}
+
+func newNotifier(paths []string) (*notifier, error) {
+	return nil, errors.New("watch is only supported on Linux")
+}
+
+// Close stops watching.
+func (n *notifier) Close() error {
+	return nil
+}
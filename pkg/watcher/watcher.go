@@ -0,0 +1,173 @@
+// Package watcher watches directories for new entries and reports them once they are completely written.
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// pollInterval is the interval at which pending entries are checked for stability.
+const pollInterval = time.Second
+
+// Options configures when a new entry is considered stable.
+type Options struct {
+	// StableFor is how long the size of an entry must stay unchanged before it is reported.
+	StableFor time.Duration
+	// IncompleteExts lists extensions of files still being downloaded, e.g. part or !qB.
+	// Entries holding such files are not stable.
+	IncompleteExts []string
+}
+
+// Watcher watches directories for new files and directories.
+// Each new entry directly inside a watched directory is reported once, when it is stable:
+// its size did not change for Options.StableFor and it does not contain incomplete files.
+// Directories which stay empty for Options.StableFor are dropped without being reported.
+type Watcher struct {
+	paths   []string
+	options Options
+	pending map[string]*pendingEntry
+}
+
+// pendingEntry is a new entry waiting to be stable.
+type pendingEntry struct {
+	size  int64     // total size of the entry at the last check
+	since time.Time // time of the last size change
+}
+
+func New(paths []string, o Options) *Watcher {
+	return &Watcher{
+		paths:   paths,
+		options: o,
+		pending: make(map[string]*pendingEntry),
+	}
+}
+
+// Run watches the directories until ctx is done, and calls handle with the path of every new entry once it is stable.
+// Entries are handled one at a time, events received meanwhile are processed afterwards.
+func (w *Watcher) Run(ctx context.Context, handle func(path string)) error {
+	n, err := newNotifier(w.paths)
+	if err != nil {
+		return err
+	}
+	defer n.Close()
+
+	for _, path := range w.paths {
+		log.Info().Str("path", path).Msg("watching")
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-n.errors:
+			return fmt.Errorf("failed to watch: %w", err)
+		case path := <-n.events:
+			if _, exists := w.pending[path]; !exists {
+				log.Debug().Str("path", path).Msg("new entry")
+			}
+			// Any change restarts the stability delay.
+			w.pending[path] = &pendingEntry{size: -1, since: time.Now()}
+		case now := <-ticker.C:
+			w.poll(now, handle)
+		}
+	}
+}
+
+// poll checks every pending entry and handles the ones which are stable.
+func (w *Watcher) poll(now time.Time, handle func(path string)) {
+	for path, e := range w.pending {
+		size, files, complete, err := w.stat(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// Entry was removed or renamed, e.g. a .part file renamed once complete, its new name has its own event.
+				log.Debug().Str("path", path).Msg("entry vanished")
+			} else {
+				log.Err(err).Str("path", path).Msg("failed to check entry")
+			}
+			delete(w.pending, path)
+			continue
+		}
+
+		if size != e.size || !complete {
+			e.size = size
+			e.since = now
+			continue
+		}
+
+		if now.Sub(e.since) < w.options.StableFor {
+			continue
+		}
+
+		delete(w.pending, path)
+		if files == 0 {
+			// Directory stayed empty, files created in it later are new events.
+			log.Debug().Str("path", path).Msg("entry is empty")
+			continue
+		}
+		log.Info().Str("path", path).Msg("entry is stable")
+		handle(path)
+	}
+}
+
+// stat returns the total size and the number of the files under path.
+// complete is false when path contains incomplete files.
+func (w *Watcher) stat(path string) (size int64, files int, complete bool, err error) {
+	complete = true
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		if w.isIncomplete(d.Name()) {
+			complete = false
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	return size, files, complete, nil
+}
+
+// isIncomplete reports whether name is the name of a file still being downloaded.
+func (w *Watcher) isIncomplete(name string) bool {
+	for _, ext := range w.options.IncompleteExts {
+		if strings.HasSuffix(name, "."+ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// topLevel returns the entry directly inside root which contains path.
+func topLevel(root, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", false
+	}
+
+	first, _, _ := strings.Cut(rel, string(os.PathSeparator))
+	return filepath.Join(root, first), true
+}
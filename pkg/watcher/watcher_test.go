@@ -0,0 +1,209 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testCase struct {
+	input    string
+	expected string
+}
+
+func TestTopLevel(t *testing.T) {
+	root := filepath.Join("watch", "downloads")
+
+	testCases := []testCase{
+		{input: filepath.Join(root, "movie.mkv"), expected: filepath.Join(root, "movie.mkv")},
+		{input: filepath.Join(root, "Show", "Season 1", "e01.mkv"), expected: filepath.Join(root, "Show")},
+		{input: filepath.Join(root, "..hidden"), expected: filepath.Join(root, "..hidden")},
+		{input: root, expected: ""},
+		{input: filepath.Join(root, ".."), expected: ""},
+		{input: filepath.Join("watch", "other", "movie.mkv"), expected: ""},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("topLevel_%03d", i), func(t *testing.T) {
+			result, ok := topLevel(root, tc.input)
+			if ok != (tc.expected != "") || result != tc.expected {
+				t.Errorf("For input '%s', expected '%s' but got '%s'", tc.input, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestIsIncomplete(t *testing.T) {
+	w := New(nil, Options{IncompleteExts: []string{"part", "!qB"}})
+
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "movie.mkv.part", expected: true},
+		{input: "movie.mkv.!qB", expected: true},
+		{input: "movie.mkv", expected: false},
+		{input: "part", expected: false},
+		{input: "movie.party.mkv", expected: false},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("isIncomplete_%03d", i), func(t *testing.T) {
+			result := w.isIncomplete(tc.input)
+			if result != tc.expected {
+				t.Errorf("For input '%s', expected '%t' but got '%t'", tc.input, tc.expected, result)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStat(t *testing.T) {
+	root := t.TempDir()
+	w := New(nil, Options{IncompleteExts: []string{"part"}})
+
+	writeFile(t, filepath.Join(root, "movie.mkv"), "abc")
+	writeFile(t, filepath.Join(root, "Show", "e01.mkv"), "ab")
+	writeFile(t, filepath.Join(root, "Show", "Extras", "e02.mkv"), "abcd")
+	writeFile(t, filepath.Join(root, "Downloading", "e01.mkv"), "ab")
+	writeFile(t, filepath.Join(root, "Downloading", "e02.mkv.part"), "a")
+	err := os.Mkdir(filepath.Join(root, "Empty"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		size     int64
+		files    int
+		complete bool
+	}{
+		{name: "movie.mkv", size: 3, files: 1, complete: true},
+		{name: "Show", size: 6, files: 2, complete: true},
+		{name: "Downloading", size: 3, files: 2, complete: false},
+		{name: "Empty", size: 0, files: 0, complete: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, files, complete, err := w.stat(filepath.Join(root, tc.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != tc.size || files != tc.files || complete != tc.complete {
+				t.Errorf("expected size %d, %d files and complete %t, got %d, %d and %t", tc.size, tc.files, tc.complete, size, files, complete)
+			}
+		})
+	}
+
+	_, _, _, err = w.stat(filepath.Join(root, "missing"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected missing entry to fail, got %v", err)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	root := t.TempDir()
+	w := New(nil, Options{StableFor: time.Minute, IncompleteExts: []string{"part"}})
+
+	movie := filepath.Join(root, "movie.mkv")
+	download := filepath.Join(root, "Download")
+	missing := filepath.Join(root, "missing.mkv")
+	writeFile(t, movie, "abc")
+	writeFile(t, filepath.Join(download, "e01.mkv.part"), "a")
+
+	start := time.Now()
+	for _, path := range []string{movie, download, missing} {
+		w.pending[path] = &pendingEntry{size: -1, since: start}
+	}
+
+	var handled []string
+	handle := func(path string) { handled = append(handled, path) }
+
+	// Sizes are recorded on the first check, vanished entries are dropped.
+	w.poll(start, handle)
+	if len(handled) > 0 {
+		t.Fatalf("expected no entry to be stable yet, got %v", handled)
+	}
+	if _, ok := w.pending[missing]; ok {
+		t.Error("expected vanished entry to be dropped")
+	}
+
+	// A change of size restarts the delay.
+	writeFile(t, movie, "abcd")
+	w.poll(start.Add(30*time.Second), handle)
+	w.poll(start.Add(time.Minute), handle)
+	if len(handled) > 0 {
+		t.Fatalf("expected changed entry not to be stable yet, got %v", handled)
+	}
+
+	w.poll(start.Add(90*time.Second), handle)
+	if !slices.Equal(handled, []string{movie}) {
+		t.Fatalf("expected %q to be stable, got %v", movie, handled)
+	}
+
+	// Entries holding incomplete files are never stable, until the files are complete.
+	w.poll(start.Add(time.Hour), handle)
+	if len(handled) != 1 {
+		t.Fatalf("expected incomplete entry not to be stable, got %v", handled)
+	}
+	err := os.Rename(filepath.Join(download, "e01.mkv.part"), filepath.Join(download, "e01.mkv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.poll(start.Add(time.Hour), handle)
+	w.poll(start.Add(time.Hour+time.Minute), handle)
+	if !slices.Equal(handled, []string{movie, download}) {
+		t.Fatalf("expected %q to be stable once complete, got %v", download, handled)
+	}
+	if len(w.pending) > 0 {
+		t.Errorf("expected no entry left pending, got %v", w.pending)
+	}
+}
+
+func TestPollEmptyDirectory(t *testing.T) {
+	root := t.TempDir()
+	w := New(nil, Options{StableFor: time.Minute})
+
+	empty := filepath.Join(root, "Empty")
+	err := os.Mkdir(empty, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	w.pending[empty] = &pendingEntry{size: -1, since: start}
+
+	var handled []string
+	handle := func(path string) { handled = append(handled, path) }
+
+	w.poll(start, handle)
+	w.poll(start.Add(30*time.Second), handle)
+	if _, ok := w.pending[empty]; !ok {
+		t.Fatal("expected empty directory to be pending until the delay elapsed")
+	}
+
+	// Directories staying empty are dropped without being handled.
+	w.poll(start.Add(time.Minute), handle)
+	if len(handled) > 0 {
+		t.Errorf("expected empty directory not to be handled, got %v", handled)
+	}
+	if len(w.pending) > 0 {
+		t.Errorf("expected empty directory to be dropped, got %v", w.pending)
+	}
+}